@@ -0,0 +1,179 @@
+package modembed
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// aliasRule 将逻辑路径 from (及其下的所有路径) 映射到底层路径 to
+type aliasRule struct {
+	from, to string
+}
+
+// WithAlias 将逻辑路径 from 映射到底层文件系统中的 to, 例如
+// WithAlias("favicon.ico", "assets/icons/favicon.ico")
+// from 为目录时映射整个子树; 多次调用时规则会累加, 最长匹配的 from 生效
+// 别名对 Open, Stat, ReadFile, ReadDir 一致生效, 并出现在所在目录的列表中
+// 修改时间, 转换等按路径匹配的设定使用逻辑路径
+func WithAlias(from, to string) Option {
+	from, to = cleanPath(from), cleanPath(to)
+	return func(o *options) {
+		o.aliases = append(o.aliases, aliasRule{from: from, to: to})
+	}
+}
+
+// WithStripPrefix 去掉打包工具产生的目录前缀, 例如 WithStripPrefix("dist") 使 dist/index.html 以 index.html 提供
+// 等价于 WithAlias(".", prefix), 前缀之外的文件将不可见
+func WithStripPrefix(prefix string) Option {
+	return WithAlias(".", prefix)
+}
+
+// aliasFS 在底层文件系统之上应用路径别名
+type aliasFS struct {
+	fsys     fs.FS
+	aliases  []aliasRule                // 按 from 长度降序, 保证最长匹配优先
+	children map[string]map[string]bool // 逻辑目录 -> 由别名产生的直接子项名称
+}
+
+func newAliasFS(fsys fs.FS, aliases []aliasRule) *aliasFS {
+	afs := &aliasFS{fsys: fsys, children: make(map[string]map[string]bool)}
+	// 后注册的同名别名覆盖先注册的
+	seen := make(map[string]bool)
+	for i := len(aliases) - 1; i >= 0; i-- {
+		if a := aliases[i]; !seen[a.from] {
+			seen[a.from] = true
+			afs.aliases = append(afs.aliases, a)
+		}
+	}
+	// "." (WithStripPrefix) 与任意路径都匹配, 总是排在最后, 否则会与单字符的 from 长度相同
+	sort.SliceStable(afs.aliases, func(i, j int) bool {
+		fi, fj := afs.aliases[i].from, afs.aliases[j].from
+		if (fi == ".") != (fj == ".") {
+			return fj == "."
+		}
+		return len(fi) > len(fj)
+	})
+	for _, a := range afs.aliases {
+		for child := a.from; child != "."; child = path.Dir(child) {
+			dir := path.Dir(child)
+			if afs.children[dir] == nil {
+				afs.children[dir] = make(map[string]bool)
+			}
+			afs.children[dir][path.Base(child)] = true
+		}
+	}
+	return afs
+}
+
+// resolve 将逻辑路径转换为底层路径
+func (afs *aliasFS) resolve(name string) string {
+	for _, a := range afs.aliases {
+		switch {
+		case a.from == ".":
+			return path.Join(a.to, name)
+		case name == a.from:
+			return a.to
+		case strings.HasPrefix(name, a.from+"/"):
+			return path.Join(a.to, name[len(a.from)+1:])
+		}
+	}
+	return name
+}
+
+func (afs *aliasFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	f, err := afs.fsys.Open(afs.resolve(name))
+	if afs.children[name] == nil {
+		return f, err
+	}
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		// 只由别名隐含的目录
+		f = &memFile{info: virtualDirInfo(name)}
+	}
+	return &aliasDir{File: f, afs: afs, name: name}, nil
+}
+
+func (afs *aliasFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := fs.Stat(afs.fsys, afs.resolve(name))
+	if err != nil && afs.children[name] != nil {
+		return virtualDirInfo(name), nil
+	}
+	return info, err
+}
+
+func (afs *aliasFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	return fs.ReadFile(afs.fsys, afs.resolve(name))
+}
+
+func (afs *aliasFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, err := fs.ReadDir(afs.fsys, afs.resolve(name))
+	kids := afs.children[name]
+	if kids == nil {
+		return entries, err
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	merged := make([]fs.DirEntry, 0, len(entries)+len(kids))
+	for _, entry := range entries {
+		if !kids[entry.Name()] {
+			merged = append(merged, entry)
+		}
+	}
+	for kid := range kids {
+		info, err := afs.Stat(path.Join(name, kid))
+		if err != nil {
+			continue // 别名指向不存在的路径时不出现在列表中
+		}
+		merged = append(merged, fs.FileInfoToDirEntry(&renamedInfo{FileInfo: info, name: kid}))
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name() < merged[j].Name() })
+	return merged, nil
+}
+
+// aliasDir 是包含别名子项的目录, ReadDir 返回合并后的条目
+type aliasDir struct {
+	fs.File
+	afs  *aliasFS
+	name string
+
+	entries []fs.DirEntry
+	loaded  bool
+	offset  int
+}
+
+func (ad *aliasDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !ad.loaded {
+		entries, err := ad.afs.ReadDir(ad.name)
+		if err != nil {
+			return nil, err
+		}
+		ad.entries, ad.loaded = entries, true
+	}
+	return readDirPage(ad.entries, &ad.offset, count)
+}
+
+// renamedInfo 以别名的名称报告底层文件的信息
+type renamedInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (ri *renamedInfo) Name() string { return ri.name }
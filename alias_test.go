@@ -0,0 +1,30 @@
+package modembed
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAliasWithStripPrefix(t *testing.T) {
+	m := fstest.MapFS{
+		"dist/index.html": {Data: []byte("index")},
+		"dist/x":          {Data: []byte("dist x")},
+		"other/x.txt":     {Data: []byte("aliased x")},
+	}
+	// 两种注册顺序都应当由更具体的 "x" 别名优先
+	for _, opts := range [][]Option{
+		{WithStripPrefix("dist"), WithAlias("x", "other/x.txt")},
+		{WithAlias("x", "other/x.txt"), WithStripPrefix("dist")},
+	} {
+		mfs := New(m, opts...)
+		data, err := fs.ReadFile(mfs, "x")
+		if err != nil || string(data) != "aliased x" {
+			t.Fatalf("ReadFile(x) = %q, %v; want %q", data, err, "aliased x")
+		}
+		data, err = fs.ReadFile(mfs, "index.html")
+		if err != nil || string(data) != "index" {
+			t.Fatalf("ReadFile(index.html) = %q, %v; want %q", data, err, "index")
+		}
+	}
+}
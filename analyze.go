@@ -0,0 +1,190 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultAnalyzeRoute 是 WithAnalyzeRoute 默认使用的路径
+const DefaultAnalyzeRoute = "debug/modembed/size.json"
+
+// SizeStat 是一组文件的数量与大小
+type SizeStat struct {
+	Files      int   `json:"files"`
+	Bytes      int64 `json:"bytes"`      // 原始大小, 即嵌入到二进制文件中的大小
+	Compressed int64 `json:"compressed"` // gzip 压缩后的估计大小, 不可压缩的文件按原始大小计
+}
+
+func (s *SizeStat) add(size, compressed int64) {
+	s.Files++
+	s.Bytes += size
+	s.Compressed += compressed
+}
+
+// Analysis 是 Analyze 的结果
+type Analysis struct {
+	Total SizeStat            `json:"total"`
+	Dirs  map[string]SizeStat `json:"dirs"` // 目录 -> 目录下 (包括子目录) 的全部文件, 根目录为 "."
+	Exts  map[string]SizeStat `json:"exts"` // 小写的扩展名 (如 ".js") -> 文件, 没有扩展名时为 ""
+
+	// Duplicates 统计与之前的文件内容相同的重复副本, 即 WithDedupe 可以在缓存中节省的部分
+	Duplicates SizeStat `json:"duplicates"`
+	// DuplicateGroups 是内容相同的路径组, 每组按路径排序, 各组按第一个路径排序
+	DuplicateGroups [][]string `json:"duplicateGroups,omitempty"`
+}
+
+// Analyze 按目录与扩展名统计底层文件系统中文件的数量, 原始大小与估计的压缩后大小
+// 用于了解哪些嵌入内容占用了二进制文件的体积; 只统计底层文件, 不包括虚拟文件与转换的结果
+// 估计压缩大小需要读取并压缩每个文件, 应在调试或构建工具中调用, 而不是在请求路径上
+func (mfs *ModTimeFS) Analyze() (Analysis, error) {
+	a := Analysis{Dirs: make(map[string]SizeStat), Exts: make(map[string]SizeStat)}
+	groups := make(map[[sha256.Size]byte]int) // 内容哈希 -> 第一个路径在 paths 中的位置
+	var paths [][]string
+	err := fs.WalkDir(mfs.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		size, compressed, sum, err := compressedSize(mfs.FS, name)
+		if err != nil {
+			return err
+		}
+		a.Total.add(size, compressed)
+		if i, ok := groups[sum]; ok {
+			paths[i] = append(paths[i], name)
+			a.Duplicates.add(size, compressed)
+		} else {
+			groups[sum] = len(paths)
+			paths = append(paths, []string{name})
+		}
+		for dir := path.Dir(name); ; dir = path.Dir(dir) {
+			s := a.Dirs[dir]
+			s.add(size, compressed)
+			a.Dirs[dir] = s
+			if dir == "." {
+				break
+			}
+		}
+		ext := strings.ToLower(path.Ext(name))
+		s := a.Exts[ext]
+		s.add(size, compressed)
+		a.Exts[ext] = s
+		return nil
+	})
+	if err != nil {
+		return Analysis{}, err
+	}
+	// WalkDir 按字典序遍历, 组内与组间的顺序已经是有序的
+	for _, group := range paths {
+		if len(group) > 1 {
+			a.DuplicateGroups = append(a.DuplicateGroups, group)
+		}
+	}
+	return a, nil
+}
+
+// compressedSize 返回 name 的原始大小, gzip 压缩后的大小与内容的 SHA-256
+func compressedSize(fsys fs.FS, name string) (size, compressed int64, sum [sha256.Size]byte, err error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return 0, 0, sum, err
+	}
+	data, err := gzipContent(context.Background(), bytes.NewReader(content))
+	if err != nil {
+		return 0, 0, sum, err
+	}
+	size, sum = int64(len(content)), sha256.Sum256(content)
+	if data == nil {
+		return size, size, sum, nil
+	}
+	return size, int64(len(data)), sum, nil
+}
+
+// WriteText 以表格形式写出按原始大小降序排列的目录与扩展名统计
+func (a Analysis) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "total: %d files, %d bytes, ~%d bytes compressed\n", a.Total.Files, a.Total.Bytes, a.Total.Compressed); err != nil {
+		return err
+	}
+	if d := a.Duplicates; d.Files > 0 {
+		if _, err := fmt.Fprintf(w, "duplicates: %d files, %d bytes, ~%d bytes compressed shareable with WithDedupe\n", d.Files, d.Bytes, d.Compressed); err != nil {
+			return err
+		}
+		for _, group := range a.DuplicateGroups {
+			if _, err := fmt.Fprintf(w, "  %s\n", strings.Join(group, ", ")); err != nil {
+				return err
+			}
+		}
+	}
+	for _, group := range []struct {
+		title string
+		stats map[string]SizeStat
+	}{{"directories", a.Dirs}, {"extensions", a.Exts}} {
+		keys := make([]string, 0, len(group.stats))
+		for k := range group.stats {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			si, sj := group.stats[keys[i]], group.stats[keys[j]]
+			if si.Bytes != sj.Bytes {
+				return si.Bytes > sj.Bytes
+			}
+			return keys[i] < keys[j]
+		})
+		if _, err := fmt.Fprintf(w, "\n%s:\n", group.title); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			s := group.stats[k]
+			label := k
+			if label == "" {
+				label = "(none)"
+			}
+			if _, err := fmt.Fprintf(w, "%12d %12d %6d  %s\n", s.Bytes, s.Compressed, s.Files, label); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WithAnalyzeRoute 让 Handler 在 name (为空时为 /debug/modembed/size.json) 上以 JSON 提供 ModTimeFS.Analyze 的结果
+// 结果在第一次请求时计算并缓存; 仅用于调试, 不应暴露在公开的服务上 (可以配合 WithAuthorize 使用)
+func WithAnalyzeRoute(name string) HandlerOption {
+	if name == "" {
+		name = DefaultAnalyzeRoute
+	}
+	name = cleanPath(name)
+	return func(h *handler) {
+		var (
+			once sync.Once
+			data []byte
+			err  error
+		)
+		h.route(name, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			once.Do(func() {
+				var a Analysis
+				if a, err = h.fsys.Analyze(); err == nil {
+					data, err = json.MarshalIndent(a, "", "\t")
+				}
+			})
+			if err != nil {
+				h.serveError(w, r, err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-cache")
+			serveBytes(w, r, name, h.fsys.ModTime(), data)
+		}))
+	}
+}
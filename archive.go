@@ -0,0 +1,103 @@
+package modembed
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// WriteTar 将整个文件树以 tar 格式写入 w
+// 条目按路径排序, 使用设定的 ModTime (截断到秒) 且不包含属主等与构建机器相关的信息
+// 因此相同的内容总是产生逐字节相同的归档
+func (mfs *ModTimeFS) WriteTar(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := fs.WalkDir(mfs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    int64(info.Mode().Perm()),
+			ModTime: info.ModTime().Truncate(time.Second),
+			Format:  tar.FormatPAX,
+		}
+		if d.IsDir() {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+			return tw.WriteHeader(hdr)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = info.Size()
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		return mfs.copyTo(tw, name)
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// WriteZip 将整个文件树以 zip 格式写入 w, 条目使用 Deflate 压缩
+// 与 WriteTar 相同, 输出只取决于文件内容与设定的修改时间
+func (mfs *ModTimeFS) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, flate.BestCompression)
+	})
+	err := fs.WalkDir(mfs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		if t := info.ModTime(); !t.IsZero() {
+			hdr.Modified = t.Truncate(time.Second)
+		}
+		if d.IsDir() {
+			hdr.Name += "/"
+			hdr.Method = zip.Store
+			hdr.SetMode(fs.ModeDir | info.Mode().Perm())
+			_, err := zw.CreateHeader(hdr)
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		hdr.SetMode(info.Mode().Perm())
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		return mfs.copyTo(fw, name)
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// copyTo 将 name 的内容写入 w
+func (mfs *ModTimeFS) copyTo(w io.Writer, name string) error {
+	f, err := mfs.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,66 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// WithAuthorize 在提供任何内容之前以请求与清理后的路径调用 fn, fn 返回 false 时拒绝请求
+// 配置了 WithAuthRealm 时返回 401 并带有 WWW-Authenticate, 否则返回 403, 例如
+//
+//	modembed.WithAuthorize(func(r *http.Request, name string) bool {
+//		return !strings.HasPrefix(name, "admin/") || isAdmin(r)
+//	})
+func WithAuthorize(fn func(r *http.Request, name string) bool) HandlerOption {
+	return func(h *handler) {
+		h.authorize = fn
+	}
+}
+
+// WithAuthRealm 设置拒绝请求时使用的 Basic 认证域, 使浏览器弹出登录框
+func WithAuthRealm(realm string) HandlerOption {
+	return func(h *handler) {
+		h.authRealm = realm
+	}
+}
+
+// BasicAuth 返回检查 HTTP Basic 认证的判断函数, 常与 WithAuthorize 和 WithAuthRealm 一起使用
+// 比较以固定时间进行, 不会因为比较耗时泄露凭据
+func BasicAuth(user, password string) func(r *http.Request) bool {
+	wantUser := sha256.Sum256([]byte(user))
+	wantPassword := sha256.Sum256([]byte(password))
+	return func(r *http.Request) bool {
+		u, p, ok := r.BasicAuth()
+		if !ok {
+			return false
+		}
+		gotUser := sha256.Sum256([]byte(u))
+		gotPassword := sha256.Sum256([]byte(p))
+		userOK := subtle.ConstantTimeCompare(gotUser[:], wantUser[:])
+		passwordOK := subtle.ConstantTimeCompare(gotPassword[:], wantPassword[:])
+		return userOK&passwordOK == 1
+	}
+}
+
+// authorized 检查请求是否被允许, 不允许时写出 401 或 403
+func (h *handler) authorized(w http.ResponseWriter, r *http.Request, name string) bool {
+	if !h.signatureValid(r, name) {
+		h.serveStatus(w, r, http.StatusForbidden, "403 Forbidden")
+		return false
+	}
+	if h.authorize == nil || h.authorize(r, name) {
+		return true
+	}
+	if h.authRealm == "" {
+		h.serveStatus(w, r, http.StatusForbidden, "403 Forbidden")
+		return false
+	}
+	realm := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(h.authRealm)
+	w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+	h.serveStatus(w, r, http.StatusUnauthorized, "401 Unauthorized")
+	return false
+}
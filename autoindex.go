@@ -0,0 +1,118 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+)
+
+// WithAutoIndex 为没有 index.html 的目录生成 HTML 目录列表 (类似 nginx 的 autoindex)
+// 列表中显示每个条目的名称, 大小和 ModTime
+func WithAutoIndex() HandlerOption {
+	return func(h *handler) {
+		h.autoIndex = true
+	}
+}
+
+// AutoIndexSort 是目录列表的排序方式
+type AutoIndexSort int
+
+const (
+	SortByName    AutoIndexSort = iota // 按名称排序 (默认)
+	SortByModTime                      // 按修改时间排序, 相同时按名称
+	SortBySize                         // 按大小排序, 相同时按名称
+)
+
+// WithAutoIndexSort 设置 WithAutoIndex 目录列表的排序方式, descending 为 true 时倒序
+// 无论哪种方式目录都排在文件前面
+func WithAutoIndexSort(by AutoIndexSort, descending bool) HandlerOption {
+	return func(h *handler) {
+		h.autoIndexSort, h.autoIndexDesc = by, descending
+	}
+}
+
+type autoIndexEntry struct {
+	Name    string
+	URL     string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+var autoIndexTemplate = template.Must(template.New("autoindex").Parse(`<!doctype html>
+<meta name="viewport" content="width=device-width">
+<title>Index of {{.Path}}</title>
+<h1>Index of {{.Path}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Last Modified</th></tr>
+{{- if ne .Path "/"}}
+<tr><td><a href="../">../</a></td><td></td><td></td></tr>
+{{- end}}
+{{- range .Entries}}
+<tr><td><a href="{{.URL}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td>{{if .IsDir}}-{{else}}{{.Size}}{{end}}</td><td>{{.ModTime.Format "2006-01-02 15:04:05"}}</td></tr>
+{{- end}}
+</table>
+`))
+
+// serveDirList 为目录 name 生成目录列表, upath 是请求中的路径
+func (h *handler) serveDirList(w http.ResponseWriter, r *http.Request, name, upath string) error {
+	dirEntries, err := fs.ReadDir(h.fsys, name)
+	if err != nil {
+		return err
+	}
+	entries := make([]autoIndexEntry, 0, len(dirEntries))
+	for _, d := range dirEntries {
+		if h.denied(path.Join(name, d.Name())) {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		e := autoIndexEntry{Name: d.Name(), IsDir: d.IsDir(), Size: info.Size(), ModTime: info.ModTime()}
+		e.URL = (&url.URL{Path: d.Name()}).String()
+		if e.IsDir {
+			e.URL += "/"
+		}
+		entries = append(entries, e)
+	}
+	// 目录排在文件前面, 同类按设定的方式排序
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		if h.autoIndexDesc {
+			a, b = b, a
+		}
+		switch h.autoIndexSort {
+		case SortByModTime:
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.Before(b.ModTime)
+			}
+		case SortBySize:
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		}
+		return a.Name < b.Name
+	})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+	// 此时响应已经开始写出, 错误只可能来自客户端连接, 忽略即可
+	_ = autoIndexTemplate.Execute(w, struct {
+		Path    string
+		Entries []autoIndexEntry
+	}{upath, entries})
+	return nil
+}
@@ -0,0 +1,53 @@
+package modembed
+
+import (
+	"embed"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+var (
+	buildTimeOnce  sync.Once
+	buildTimeValue time.Time
+	buildTimeOK    bool
+)
+
+// BuildTime 返回当前二进制的构建时间
+// 优先使用构建信息中的 vcs.time (最后一次提交的时间), 其次使用可执行文件自身的修改时间
+// 两者都无法获取时返回 false; 结果在首次调用后缓存
+func BuildTime() (time.Time, bool) {
+	buildTimeOnce.Do(func() {
+		buildTimeValue, buildTimeOK = readBuildTime()
+	})
+	return buildTimeValue, buildTimeOK
+}
+
+func readBuildTime() (time.Time, bool) {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.time" {
+				if t, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+					return t.UTC(), true
+				}
+			}
+		}
+	}
+	if exe, err := os.Executable(); err == nil {
+		if info, err := os.Stat(exe); err == nil {
+			return info.ModTime().UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// processStart 在无法获取构建时间时作为回退值, 至少保证同一进程内的 304 行为正确
+var processStart = time.Now().UTC()
+
+// NewModTimeFSWithBuildTime 使用 BuildTime 作为所有文件的修改时间
+// 这样新版本发布后 Last-Modified 会自动变化, 无需维护一个手写的时间常量
+// 无法获取构建时间时回退到进程启动时间
+func NewModTimeFSWithBuildTime(efs embed.FS) *ModTimeFS {
+	return New(efs, WithBuildTime())
+}
@@ -0,0 +1,59 @@
+package modembed
+
+import (
+	"sort"
+	"strings"
+)
+
+// CacheRule 将匹配 Pattern 的路径映射到一个 Cache-Control 值
+type CacheRule struct {
+	Pattern string // path.Match 语法, 不含 "/" 时匹配文件名, 否则匹配完整路径
+	Value   string // Cache-Control 头的值
+}
+
+// CachePolicy 是一组按顺序匹配的缓存规则, 第一条匹配的规则生效
+type CachePolicy []CacheRule
+
+// NewCachePolicy 从 模式 -> Cache-Control 的映射创建 CachePolicy
+// 由于映射无序, 规则按具体程度排序: 不含通配符的模式优先, 其次是较长的模式
+func NewCachePolicy(rules map[string]string) CachePolicy {
+	p := make(CachePolicy, 0, len(rules))
+	for pattern, value := range rules {
+		p = append(p, CacheRule{Pattern: pattern, Value: value})
+	}
+	sort.Slice(p, func(i, j int) bool {
+		wi, wj := hasMeta(p[i].Pattern), hasMeta(p[j].Pattern)
+		if wi != wj {
+			return !wi
+		}
+		if len(p[i].Pattern) != len(p[j].Pattern) {
+			return len(p[i].Pattern) > len(p[j].Pattern)
+		}
+		return p[i].Pattern < p[j].Pattern
+	})
+	return p
+}
+
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// CacheControl 返回 name 对应的 Cache-Control 值, 没有匹配的规则时返回空字符串
+func (p CachePolicy) CacheControl(name string) string {
+	for _, rule := range p {
+		if matchPattern(rule.Pattern, name) {
+			return rule.Value
+		}
+	}
+	return ""
+}
+
+// DefaultCachePolicy 返回启用 WithFingerprints 且没有设置 WithCachePolicy 时使用的策略
+// 带指纹的路径总是得到长期不可变的 Cache-Control; 未加指纹的 HTML 每次都需要重新验证, 其余文件缓存 5 分钟
+// 可以在修改后传给 WithCachePolicy
+func DefaultCachePolicy() CachePolicy {
+	return CachePolicy{
+		{Pattern: "*.html", Value: "no-cache"},
+		{Pattern: "*", Value: "public, max-age=300"},
+	}
+}
@@ -0,0 +1,150 @@
+package modembed
+
+import (
+	"sync/atomic"
+)
+
+// 由 CacheBudget 管理的缓存, 也是 CacheBudget.Stats 中的键
+const (
+	CacheCompression  = "compression"  // WithCompression 的压缩结果
+	CacheMaterialized = "materialized" // Materialize 载入内存的文件
+	CacheNegative     = "negative"     // WithNegativeCache 记录的缺失路径
+	CacheTransform    = "transform"    // WithTransform 的转换结果
+)
+
+var cacheKinds = []string{CacheCompression, CacheMaterialized, CacheNegative, CacheTransform}
+
+// missEntryCost 是计入预算的每条缺失记录的开销 (不含路径本身)
+const missEntryCost = 64
+
+// CacheStats 是一个缓存的统计
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64 // 因超出预算而被淘汰的项数
+	Entries   int64  // 当前的项数
+	Bytes     int64  // 当前占用的字节数
+}
+
+// CacheBudget 是由多个内存缓存共享的总字节数上限, 超出时按 LRU 淘汰任意缓存中最久未使用的项
+// 同一个 CacheBudget 可以传给多个 ModTimeFS, 以限制整个 modembed 层使用的内存
+//
+//	budget := modembed.NewCacheBudget(64 << 20)
+//	mfs := modembed.New(content, modembed.WithCacheBudget(budget), modembed.WithNegativeCache(1, 0))
+//	h := modembed.Handler(mfs, modembed.WithCompression(1024, 0))
+type CacheBudget struct {
+	lru   *lruCache[budgetKey, any]
+	stats map[string]*cacheCounters // 创建后只读
+}
+
+type budgetKey struct {
+	kind  string
+	owner any // 区分共享预算的各个 ModTimeFS 或 Handler
+	name  string
+}
+
+type cacheCounters struct {
+	hits, misses, evictions atomic.Uint64
+	entries, bytes          atomic.Int64
+}
+
+// NewCacheBudget 创建总大小不超过 maxBytes 的缓存预算
+// 单个超过 maxBytes 的项不会被缓存
+func NewCacheBudget(maxBytes int64) *CacheBudget {
+	b := &CacheBudget{lru: newLRU[budgetKey, any](maxBytes), stats: make(map[string]*cacheCounters, len(cacheKinds))}
+	for _, kind := range cacheKinds {
+		b.stats[kind] = new(cacheCounters)
+	}
+	b.lru.onRemove = func(key budgetKey, cost int64, evicted bool) {
+		c := b.stats[key.kind]
+		c.entries.Add(-1)
+		c.bytes.Add(-cost)
+		if evicted {
+			c.evictions.Add(1)
+		}
+	}
+	return b
+}
+
+// WithCacheBudget 让 ModTimeFS 的转换结果, Materialize 载入的文件, 缺失路径记录,
+// 以及使用它的 Handler 的压缩结果都计入 b, 而不是各自独立的缓存
+// 此时 WithCompression 的 cacheBytes 与 WithNegativeCache 的 size 不再限制大小;
+// Materialize 载入的文件可能被淘汰, 之后按普通文件从底层文件系统读取
+func WithCacheBudget(b *CacheBudget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// Stats 返回每个缓存的统计, 键为 CacheCompression 等常量
+func (b *CacheBudget) Stats() map[string]CacheStats {
+	stats := make(map[string]CacheStats, len(b.stats))
+	for kind, c := range b.stats {
+		stats[kind] = CacheStats{
+			Hits:      c.hits.Load(),
+			Misses:    c.misses.Load(),
+			Evictions: c.evictions.Load(),
+			Entries:   c.entries.Load(),
+			Bytes:     c.bytes.Load(),
+		}
+	}
+	return stats
+}
+
+// budgetRegion 是 CacheBudget 中属于一个缓存与一个所有者的部分
+type budgetRegion[V any] struct {
+	b     *CacheBudget
+	kind  string
+	owner any
+}
+
+func newBudgetRegion[V any](b *CacheBudget, kind string, owner any) budgetRegion[V] {
+	return budgetRegion[V]{b: b, kind: kind, owner: owner}
+}
+
+func (r budgetRegion[V]) key(name string) budgetKey {
+	return budgetKey{kind: r.kind, owner: r.owner, name: name}
+}
+
+func (r budgetRegion[V]) get(name string) (V, bool) {
+	c := r.b.stats[r.kind]
+	if v, ok := r.b.lru.get(r.key(name)); ok {
+		c.hits.Add(1)
+		return v.(V), true
+	}
+	c.misses.Add(1)
+	var zero V
+	return zero, false
+}
+
+func (r budgetRegion[V]) contains(name string) bool {
+	return r.b.lru.contains(r.key(name))
+}
+
+func (r budgetRegion[V]) add(name string, value V, cost int64) {
+	key := r.key(name)
+	// 在加入之前计数, 使加入时立即发生的淘汰 (包括淘汰自身) 正确地抵消
+	if cost <= r.b.lru.maxCost {
+		c := r.b.stats[r.kind]
+		c.entries.Add(1)
+		c.bytes.Add(cost)
+	}
+	r.b.lru.add(key, value, cost)
+}
+
+func (r budgetRegion[V]) remove(name string) {
+	r.b.lru.remove(r.key(name))
+}
+
+func (r budgetRegion[V]) clear() {
+	r.b.lru.removeIf(func(key budgetKey) bool { return key.kind == r.kind && key.owner == r.owner })
+}
+
+// cacheStore 是 lruCache 与 budgetRegion 共同实现的缓存接口
+type cacheStore[V any] interface {
+	get(key string) (V, bool)
+	contains(key string) bool
+	add(key string, value V, cost int64)
+	remove(key string)
+	clear()
+}
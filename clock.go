@@ -0,0 +1,39 @@
+package modembed
+
+import "time"
+
+// Clock 是包内读取当前时间的来源, 测试中可以通过 WithClock 注入固定或可控的时钟
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock 使用 time.Now
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// FixedClock 是总是返回同一时间的 Clock
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time { return time.Time(c) }
+
+// WithClock 设置 ModTimeFS 以及基于它的 Handler 读取当前时间的方式
+// 影响 WithBuildTime 无法获取构建时间时的回退值, WithNegativeCache 的过期, 以及日志, 指标与 Preload 中的耗时
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// now 返回 mfs 的时钟给出的当前时间
+func (mfs *ModTimeFS) now() time.Time {
+	if mfs.clock == nil {
+		return time.Now()
+	}
+	return mfs.clock.Now()
+}
+
+// since 返回从 start 到当前时间经过的时长
+func (mfs *ModTimeFS) since(start time.Time) time.Duration {
+	return mfs.now().Sub(start)
+}
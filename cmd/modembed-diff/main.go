@@ -0,0 +1,84 @@
+// modembed-diff 比较两个清单, 列出新增, 删除与内容变化的文件以及大小的变化
+// 清单可以是 modembed-gen 生成的 JSON 文件, 也可以是运行中实例的 WithManifestRoute 地址
+//
+//	modembed-diff old.json new.json
+//	modembed-diff -exit-code https://example.com/.well-known/assets.json dist/modtimes.json
+//
+// 在 CI 中可以用来展示一次发布实际改变了哪些资源
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/wjqserver/modembed"
+)
+
+func main() {
+	var (
+		exitCode = flag.Bool("exit-code", false, "有差异时以状态码 1 退出")
+		jsonOut  = flag.Bool("json", false, "以 JSON 格式输出报告")
+	)
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: modembed-diff [flags] old new")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	a, err := load(flag.Arg(0))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	b, err := load(flag.Arg(1))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	r := modembed.Diff(a, b)
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(r)
+	} else {
+		err = r.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if *exitCode && !r.Empty() {
+		os.Exit(1)
+	}
+}
+
+// load 从文件或 http(s) 地址读取清单
+func load(src string) (modembed.Manifest, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return modembed.ReadManifest(f)
+	}
+	resp, err := http.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: %s", src, resp.Status)
+	}
+	return modembed.ReadManifest(resp.Body)
+}
+
+func fatalf(msg string, args ...any) {
+	fmt.Fprintf(os.Stderr, "modembed-diff: "+msg+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,206 @@
+// modembed-gen 遍历源目录, 记录每个文件的真实修改时间 (以及大小和可选的 SHA-256)
+// 并输出 JSON 清单或 Go 源文件, 供 modembed.NewModTimeFSFromManifest 使用
+//
+// 典型用法 (与 //go:embed static 配合):
+//
+//	//go:generate go run github.com/wjqserver/modembed/cmd/modembed-gen -dir static -prefix static -o modtimes_gen.go
+//
+// 输出文件后缀为 .go 时生成 Go 源文件, 否则生成 JSON; 也可以用 -format 显式指定
+// 指定 -hints 时同时从入口页面扫描引用的资源, 写出供 modembed.Preload 使用的预热顺序
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wjqserver/modembed"
+)
+
+func main() {
+	var (
+		dir     = flag.String("dir", ".", "要遍历的源目录")
+		prefix  = flag.String("prefix", "", "添加到每个路径前的前缀, 应与 //go:embed 中的目录一致")
+		out     = flag.String("o", "-", "输出文件, - 表示标准输出")
+		formatF = flag.String("format", "", "输出格式 json 或 go, 默认根据输出文件后缀判断")
+		pkg     = flag.String("pkg", os.Getenv("GOPACKAGE"), "生成 Go 源文件时使用的包名")
+		varName = flag.String("var", "Manifest", "生成 Go 源文件时使用的变量名")
+		hash    = flag.Bool("hash", false, "记录每个文件内容的 SHA-256")
+		all     = flag.Bool("all", false, "包含以 . 或 _ 开头的文件, 与 //go:embed all: 行为一致")
+		hints   = flag.String("hints", "", "同时将从入口页面扫描出的预热顺序写入该文件, 通常为 <dir>/"+modembed.PreloadHintsFile)
+		entries = flag.String("entry", "index.html", "扫描预热顺序的入口页面, 相对于 -dir, 多个以逗号分隔")
+		urlPref = flag.String("url-prefix", "/", "页面中引用资源时使用的 URL 前缀")
+	)
+	flag.Parse()
+
+	outFormat := *formatF
+	if outFormat == "" {
+		outFormat = "json"
+		if strings.HasSuffix(*out, ".go") {
+			outFormat = "go"
+		}
+	}
+	if outFormat != "json" && outFormat != "go" {
+		fatalf("unknown format %q", outFormat)
+	}
+	if outFormat == "go" && *pkg == "" {
+		fatalf("-pkg is required when generating Go source outside of go generate")
+	}
+
+	skip := ""
+	if *out != "-" {
+		if abs, err := filepath.Abs(*out); err == nil {
+			skip = abs
+		}
+	}
+
+	m, err := collect(*dir, *prefix, *hash, *all, skip)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	var data []byte
+	switch outFormat {
+	case "go":
+		data, err = renderGo(m, *pkg, *varName)
+	default:
+		var buf bytes.Buffer
+		err = m.WriteJSON(&buf)
+		data = buf.Bytes()
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	if *out == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*out, data, 0o644)
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	if *hints != "" {
+		if err := writeHints(*dir, *urlPref, strings.Split(*entries, ","), *hints); err != nil {
+			fatalf("%v", err)
+		}
+	}
+}
+
+// writeHints 从 entries 扫描 root 中资源的引用关系, 将预热顺序以 JSON 数组写入 out
+func writeHints(root, urlPrefix string, entries []string, out string) error {
+	list, err := modembed.ScanPreloadHints(os.DirFS(root), urlPrefix, entries...)
+	if err != nil {
+		return err
+	}
+	if list == nil {
+		list = []string{}
+	}
+	data, err := json.MarshalIndent(list, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, append(data, '\n'), 0o644)
+}
+
+// collect 遍历 root 并生成清单, skip 为需要跳过的文件绝对路径 (通常是输出文件本身)
+func collect(root, prefix string, withHash, all bool, skip string) (modembed.Manifest, error) {
+	var m modembed.Manifest
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != root && !all && isHidden(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		if skip != "" {
+			if abs, err := filepath.Abs(p); err == nil && abs == skip {
+				return nil
+			}
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		e := modembed.Entry{
+			Path:    path.Join(prefix, filepath.ToSlash(rel)),
+			Size:    info.Size(),
+			ModTime: info.ModTime().UTC(),
+		}
+		if withHash {
+			if e.SHA256, err = hashFile(p); err != nil {
+				return err
+			}
+		}
+		m = append(m, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(m, func(i, j int) bool { return m[i].Path < m[j].Path })
+	return m, nil
+}
+
+// isHidden 判断文件名是否会被 //go:embed 目录模式默认排除
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func renderGo(m modembed.Manifest, pkg, varName string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by modembed-gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import (\n\t\"time\"\n\n\t\"github.com/wjqserver/modembed\"\n)\n\n")
+	fmt.Fprintf(&buf, "var _ = time.Unix\n\n")
+	fmt.Fprintf(&buf, "var %s = modembed.Manifest{\n", varName)
+	for _, e := range m {
+		fmt.Fprintf(&buf, "\t{Path: %q, Size: %d, ModTime: time.Unix(%d, %d).UTC()", e.Path, e.Size, e.ModTime.Unix(), e.ModTime.Nanosecond())
+		if e.SHA256 != "" {
+			fmt.Fprintf(&buf, ", SHA256: %q", e.SHA256)
+		}
+		fmt.Fprintf(&buf, "},\n")
+	}
+	fmt.Fprintf(&buf, "}\n")
+	return format.Source(buf.Bytes())
+}
+
+func fatalf(msg string, args ...any) {
+	fmt.Fprintf(os.Stderr, "modembed-gen: "+msg+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wjqserver/modembed"
+)
+
+// modembed-gen -hash 的输出必须能直接用于 ModTimeFS.Verify, 即使文件系统配置了转换
+func TestCollectHashVerify(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "static")
+	files := map[string]string{
+		"index.html":       "<p>  hello  </p>",
+		"js/app.js":        "console.log(1)\n//# sourceMappingURL=app.js.map\n",
+		"js/app.js.map":    "{}",
+		"css/site.css":     "body { margin: 0 }\n/*# sourceMappingURL=site.css.map */\n",
+		"css/site.css.map": "{}",
+	}
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := collect(dir, "static", true, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mfs := modembed.New(os.DirFS(tmp),
+		modembed.WithModTime(time.Unix(1e9, 0)),
+		modembed.WithStripPrefix("static"),
+		modembed.WithSourceMaps(false),
+		modembed.WithTransform("*.html", func(_ string, data []byte) ([]byte, error) {
+			return bytes.ReplaceAll(data, []byte("  "), nil), nil
+		}),
+	)
+	mfs.AddVirtual("version.txt", []byte("v1"), time.Time{})
+	if err := mfs.Verify(m.Hashes()); err != nil {
+		t.Fatal(err)
+	}
+}
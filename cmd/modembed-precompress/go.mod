@@ -0,0 +1,11 @@
+module github.com/wjqserver/modembed/cmd/modembed-precompress
+
+go 1.24.3
+
+replace github.com/wjqserver/modembed => ../../
+
+require (
+	github.com/andybalholm/brotli v1.1.0
+	github.com/klauspost/compress v1.17.9
+	github.com/wjqserver/modembed v0.0.0-00010101000000-000000000000
+)
@@ -0,0 +1,172 @@
+// modembed-precompress 在构建时为静态资源生成预压缩的兄弟文件 (.br, .zst, .gz) 以及索引文件
+// 配合 modembed.WithPrecompressed 使用, 运行时直接提供压缩好的内容, 不消耗 CPU 且可以使用最高压缩级别
+//
+//	//go:generate go run github.com/wjqserver/modembed/cmd/modembed-precompress -dir static
+//
+// 压缩后没有明显变小的文件不会生成对应的变体; 变体的修改时间与源文件一致, 以免影响 modembed-gen 的清单
+// 索引写入 -dir 下的 .precompressed.json (modembed.PrecompressedIndex), Handler 会自动使用它
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/wjqserver/modembed"
+)
+
+// defaultPatterns 是默认压缩的文件类型, 图片, 字体 (除 SVG 外) 与归档已经是压缩格式
+const defaultPatterns = "*.html,*.htm,*.css,*.js,*.mjs,*.json,*.map,*.svg,*.xml,*.txt,*.wasm,*.webmanifest,*.ico,*.ttf,*.otf"
+
+// encoder 将 src 压缩为一种编码
+type encoder struct {
+	suffix string
+	encode func(w io.Writer, src []byte) error
+}
+
+var encoders = map[string]encoder{
+	"br": {".br", func(w io.Writer, src []byte) error {
+		bw := brotli.NewWriterLevel(w, brotli.BestCompression)
+		if _, err := bw.Write(src); err != nil {
+			return err
+		}
+		return bw.Close()
+	}},
+	"zstd": {".zst", func(w io.Writer, src []byte) error {
+		zw, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+		if err != nil {
+			return err
+		}
+		if _, err := zw.Write(src); err != nil {
+			return err
+		}
+		return zw.Close()
+	}},
+	"gzip": {".gz", func(w io.Writer, src []byte) error {
+		gw, _ := gzip.NewWriterLevel(w, gzip.BestCompression)
+		if _, err := gw.Write(src); err != nil {
+			return err
+		}
+		return gw.Close()
+	}},
+}
+
+func main() {
+	var (
+		dir       = flag.String("dir", ".", "要处理的目录")
+		encodings = flag.String("encodings", "br,zstd,gzip", "生成的编码, 以逗号分隔")
+		patterns  = flag.String("patterns", defaultPatterns, "需要压缩的文件模式, 以逗号分隔, 语法同 modembed.CachePolicy")
+		minSize   = flag.Int64("min", 1024, "小于该字节数的文件不压缩")
+		minRatio  = flag.Float64("ratio", 0.95, "压缩后的大小超过原始大小的该比例时不生成变体")
+		noIndex   = flag.Bool("no-index", false, "不写出索引文件")
+	)
+	flag.Parse()
+
+	var encs []string
+	for _, enc := range strings.Split(*encodings, ",") {
+		enc = strings.TrimSpace(enc)
+		if _, ok := encoders[enc]; !ok {
+			fatalf("unknown encoding %q", enc)
+		}
+		encs = append(encs, enc)
+	}
+	pats := strings.Split(*patterns, ",")
+
+	index := make(map[string][]string)
+	var files, variants int
+	root := os.DirFS(*dir)
+	err := fs.WalkDir(root, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !d.Type().IsRegular() || !matchAny(pats, name) || name == modembed.PrecompressedIndex {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() < *minSize {
+			return removeVariants(*dir, name, encs)
+		}
+		src, err := fs.ReadFile(root, name)
+		if err != nil {
+			return err
+		}
+		files++
+		for _, enc := range encs {
+			e := encoders[enc]
+			target := filepath.Join(*dir, filepath.FromSlash(name)+e.suffix)
+			var buf bytes.Buffer
+			if err := e.encode(&buf, src); err != nil {
+				return fmt.Errorf("%s: %s: %w", name, enc, err)
+			}
+			if float64(buf.Len()) > float64(len(src))**minRatio {
+				if err := removeVariants(*dir, name, []string{enc}); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := os.WriteFile(target, buf.Bytes(), 0o644); err != nil {
+				return err
+			}
+			if err := os.Chtimes(target, info.ModTime(), info.ModTime()); err != nil {
+				return err
+			}
+			index[name] = append(index[name], enc)
+			variants++
+		}
+		return nil
+	})
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	if !*noIndex {
+		data, err := json.MarshalIndent(index, "", "\t")
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if err := os.WriteFile(filepath.Join(*dir, modembed.PrecompressedIndex), append(data, '\n'), 0o644); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "modembed-precompress: compressed %d files, wrote %d variants\n", files, variants)
+}
+
+// removeVariants 删除 name 之前生成的变体, 避免过期的变体被优先提供
+func removeVariants(dir, name string, encs []string) error {
+	for _, enc := range encs {
+		target := filepath.Join(dir, filepath.FromSlash(name)+encoders[enc].suffix)
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchAny 的语义与 modembed 的模式一致: 不含 "/" 的模式只匹配文件名
+func matchAny(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "/")
+		target := name
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(name)
+		}
+		ok, err := path.Match(pattern, target)
+		return err == nil && ok
+	})
+}
+
+func fatalf(msg string, args ...any) {
+	fmt.Fprintf(os.Stderr, "modembed-precompress: "+msg+"\n", args...)
+	os.Exit(1)
+}
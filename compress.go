@@ -0,0 +1,186 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultCompressionCache 是未指定时压缩缓存的总大小上限
+const defaultCompressionCache = 32 << 20
+
+// compressor 保存即时压缩的设定与压缩结果的缓存
+type compressor struct {
+	minSize int64
+	cache   cacheStore[*compressedEntry] // 路径 + "\x00" + 编码 -> 压缩结果
+	flight  flightGroup[string, *compressedEntry]
+}
+
+// compressedEntry 记录一个文件的压缩结果, 失效规则同 digestEntry
+// data 为 nil 表示压缩后没有变小, 直接提供原始内容
+type compressedEntry struct {
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+// WithCompression 启用即时 gzip 压缩, 与 WithPrecompressed 的兄弟文件相互独立
+// 大小不小于 minSize 且类型可压缩 (文本, JavaScript, JSON, XML, SVG, WebAssembly 等) 的文件
+// 在客户端接受 gzip 时压缩后返回; 存在可用的预压缩变体时优先使用预压缩变体
+// 压缩结果按 路径+编码 (设置了 WithDedupe 时按内容) 缓存在内存中, 总大小不超过 cacheBytes (LRU 淘汰), 因此每个文件在进程内最多压缩一次
+// cacheBytes <= 0 时使用 32 MiB; 文件系统设置了 WithCacheBudget 时压缩结果计入该预算, cacheBytes 被忽略
+func WithCompression(minSize int64, cacheBytes int64) HandlerOption {
+	if cacheBytes <= 0 {
+		cacheBytes = defaultCompressionCache
+	}
+	return func(h *handler) {
+		c := &compressor{minSize: minSize}
+		if b := h.fsys.budget; b != nil {
+			c.cache = newBudgetRegion[*compressedEntry](b, CacheCompression, c)
+		} else {
+			c.cache = newLRU[string, *compressedEntry](cacheBytes)
+		}
+		h.compression = c
+	}
+}
+
+// compressible 判断 ctype 是否值得压缩, 已经压缩过的格式 (图片, 视频, 归档等) 不再压缩
+func compressible(ctype string) bool {
+	ctype, _, _ = strings.Cut(ctype, ";")
+	ctype = strings.TrimSpace(strings.ToLower(ctype))
+	switch {
+	case strings.HasPrefix(ctype, "text/"),
+		strings.HasSuffix(ctype, "+json"), strings.HasSuffix(ctype, "+xml"):
+		return true
+	}
+	switch ctype {
+	case "application/javascript", "application/json", "application/xml",
+		"application/wasm", "image/svg+xml", "image/x-icon", "image/vnd.microsoft.icon",
+		"font/ttf", "font/otf", "application/vnd.ms-fontobject":
+		return true
+	}
+	return false
+}
+
+// serveCompressed 在适用时提供 name 即时压缩后的内容, 返回是否已经写出响应
+func (h *handler) serveCompressed(w http.ResponseWriter, r *http.Request, name string, f fs.File, info fs.FileInfo) bool {
+	if info.Size() < h.compression.minSize {
+		return false
+	}
+	ctype := h.contentType(name)
+	if !compressible(ctype) {
+		return false
+	}
+	addVary(w.Header(), "Accept-Encoding")
+	if len(negotiateEncodings(r.Header.Get("Accept-Encoding"), []string{"gzip"})) == 0 {
+		return false
+	}
+
+	e, err := h.compression.entry(r.Context(), h.fsys, name, info)
+	if err != nil && r.Context().Err() != nil {
+		return true // 客户端已经离开, 不再提供未压缩的内容
+	}
+	if err != nil || e.data == nil {
+		return false
+	}
+
+	w.Header().Set("Content-Type", ctype)
+	if etag, ok := h.fsys.ETag(name); ok {
+		// 压缩后的表示是不同的字节序列, 使用不同的强 ETag
+		w.Header().Set("ETag", strings.TrimSuffix(etag, `"`)+`-gzip"`)
+	}
+	serveMemory(&encodingWriter{ResponseWriter: w, encoding: "gzip", size: int64(len(e.data))}, r, info.Name(), info.ModTime(), e.data)
+	return true
+}
+
+// entry 返回 name 的压缩结果, 缓存中没有或已经过期时从 fsys 读取并压缩后写入缓存
+// ctx 结束时放弃等待; 压缩在所有等待它的请求都结束后停止
+func (c *compressor) entry(ctx context.Context, fsys *ModTimeFS, name string, info fs.FileInfo) (*compressedEntry, error) {
+	ctx, span := startSpan(ctx, SpanCompress)
+	e, hit, err := c.lookup(ctx, fsys, name, info)
+	if span != nil {
+		span.SetAttr(AttrPath, name)
+		span.SetAttr(AttrEncoding, "gzip")
+		span.SetAttr(AttrCacheHit, hit)
+		if err == nil && e.data != nil {
+			span.SetAttr(AttrSize, int64(len(e.data)))
+		}
+		span.End(err)
+	}
+	return e, err
+}
+
+// lookup 返回 name 的压缩结果以及它是否来自缓存
+func (c *compressor) lookup(ctx context.Context, fsys *ModTimeFS, name string, info fs.FileInfo) (*compressedEntry, bool, error) {
+	key, shared := fsys.contentKey(name)
+	key += "\x00gzip"
+	// 按内容缓存的结果与修改时间无关, 内容相同的其他路径也可以使用
+	if e, ok := c.cache.get(key); ok && e.size == info.Size() && (shared || e.modTime.Equal(info.ModTime())) {
+		return e, true, nil
+	}
+	// 并发的首次请求只压缩一次; 压缩使用自己打开的文件, 不依赖某一个请求的生命周期
+	e, err := c.flight.do(ctx, key, func(ctx context.Context) (*compressedEntry, error) {
+		f, err := fsys.OpenContext(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		data, err := gzipContent(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		e := &compressedEntry{size: info.Size(), modTime: info.ModTime(), data: data}
+		c.cache.add(key, e, int64(len(data)))
+		return e, nil
+	})
+	return e, false, err
+}
+
+// gzipChunk 是 gzipContent 两次检查 ctx 之间压缩的字节数
+const gzipChunk = 256 << 10
+
+// gzipContent 压缩 r 的全部内容, 压缩后没有变小时返回 nil; ctx 结束时停止并返回 ctx.Err()
+func gzipContent(ctx context.Context, r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	for rest := data; len(rest) > 0; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n := min(len(rest), gzipChunk)
+		if _, err := zw.Write(rest[:n]); err != nil {
+			return nil, err
+		}
+		rest = rest[n:]
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if buf.Len() >= len(data) {
+		return nil, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// addVary 在 Vary 中加入 value, 已经存在时不重复加入
+func addVary(header http.Header, value string) {
+	for _, v := range header.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), value) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", value)
+}
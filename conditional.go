@@ -0,0 +1,189 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CheckNotModified 验证 h 对 urlPath 的条件请求处理是否正确, 用于在测试中断言 304 行为
+// 依次检查:
+//   - 普通 GET 返回 200, 并带有 ETag 与 Last-Modified
+//   - If-None-Match 使用相同的 ETag (包括弱比较形式 W/"...") 时返回 304
+//   - If-Modified-Since 使用 Last-Modified 的值时返回 304
+//   - HEAD 的条件请求同样返回 304
+//   - 304 响应不含消息体
+//
+// 全部通过时返回 nil, 否则返回描述第一个失败项的错误, 例如
+//
+//	if err := modembed.CheckNotModified(h, "/app.js"); err != nil {
+//		t.Fatal(err)
+//	}
+func CheckNotModified(h http.Handler, urlPath string) error {
+	rec := serveRecorded(h, http.MethodGet, urlPath, nil)
+	if rec.code != http.StatusOK {
+		return fmt.Errorf("modembed: GET %s: status %d, want 200", urlPath, rec.code)
+	}
+	etag := rec.header.Get("ETag")
+	if etag == "" {
+		return fmt.Errorf("modembed: GET %s: missing ETag", urlPath)
+	}
+	lastModified := rec.header.Get("Last-Modified")
+	if lastModified == "" {
+		return fmt.Errorf("modembed: GET %s: missing Last-Modified", urlPath)
+	}
+
+	weak := etag
+	if len(etag) < 2 || etag[:2] != "W/" {
+		weak = "W/" + etag
+	}
+	checks := []struct {
+		method, header, value string
+	}{
+		{http.MethodGet, "If-None-Match", etag},
+		{http.MethodGet, "If-None-Match", weak},
+		{http.MethodGet, "If-None-Match", `"modembed-mismatch", ` + etag},
+		{http.MethodGet, "If-Modified-Since", lastModified},
+		{http.MethodHead, "If-None-Match", etag},
+		{http.MethodHead, "If-Modified-Since", lastModified},
+	}
+	for _, c := range checks {
+		rec := serveRecorded(h, c.method, urlPath, http.Header{c.header: {c.value}})
+		if rec.code != http.StatusNotModified {
+			return fmt.Errorf("modembed: %s %s with %s: %s: status %d, want 304", c.method, urlPath, c.header, c.value, rec.code)
+		}
+		if rec.body > 0 {
+			return fmt.Errorf("modembed: %s %s with %s: %s: 304 response has a body", c.method, urlPath, c.header, c.value)
+		}
+	}
+
+	// 不匹配的 ETag 必须返回完整内容, 且 If-None-Match 优先于 If-Modified-Since
+	rec = serveRecorded(h, http.MethodGet, urlPath, http.Header{
+		"If-None-Match":     {`"modembed-mismatch"`},
+		"If-Modified-Since": {lastModified},
+	})
+	if rec.code != http.StatusOK {
+		return fmt.Errorf("modembed: GET %s with a mismatched If-None-Match: status %d, want 200", urlPath, rec.code)
+	}
+	return nil
+}
+
+// CheckConditional 按 RFC 9110 评估 r 中的条件请求头, 与 Handler (即 http.ServeContent) 的 304 与 412 语义相同
+// 用于渲染模板等不经过文件系统的处理器, 调用方负责的资源应当存在 (因此 If-Match: * 与 If-None-Match: * 总是命中)
+// modTime 为零值或 etag 为空时对应的条件被忽略, etag 需要带引号 (例如 "v1" 或 W/"v1")
+// done 为 true 时调用方应直接以 status (304 或 412) 响应而不写出消息体, 否则 status 为 200, 例如
+//
+//	if status, done := modembed.CheckConditional(r, modTime, etag); done {
+//		w.WriteHeader(status)
+//		return
+//	}
+//
+// 对 304 响应, 调用方仍应设置 ETag 与 Last-Modified
+func CheckConditional(r *http.Request, modTime time.Time, etag string) (status int, done bool) {
+	// If-Match 与 If-Unmodified-Since 使用强比较, 失败时返回 412
+	if im := r.Header.Get("If-Match"); im != "" {
+		if !etagListMatch(im, etag, false) {
+			return http.StatusPreconditionFailed, true
+		}
+	} else if ius := r.Header.Get("If-Unmodified-Since"); ius != "" && !modTime.IsZero() {
+		if t, err := http.ParseTime(ius); err == nil && modTime.Truncate(time.Second).After(t) {
+			return http.StatusPreconditionFailed, true
+		}
+	}
+
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+	// If-None-Match 存在时忽略 If-Modified-Since
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etagListMatch(inm, etag, true) {
+			if safe {
+				return http.StatusNotModified, true
+			}
+			return http.StatusPreconditionFailed, true
+		}
+		return http.StatusOK, false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && safe && !modTime.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil && !modTime.Truncate(time.Second).After(t) {
+			return http.StatusNotModified, true
+		}
+	}
+	return http.StatusOK, false
+}
+
+// etagListMatch 判断以逗号分隔的 ETag 列表 (或 *) 是否与 etag 匹配, weak 为 true 时使用弱比较
+// * 表示存在任意当前表示, 与 etag 无关; 调用 CheckConditional 时资源总是存在
+func etagListMatch(list, etag string, weak bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if candidate == etag && !strings.HasPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ConditionalRequest 返回用于测试条件请求的 GET 请求
+// modSince 非零时设置 If-Modified-Since (按 HTTP 日期格式, 精确到秒), etag 非空时设置 If-None-Match
+// 与 httptest.NewRequest 相同, target 无效时 panic
+//
+//	r := modembed.ConditionalRequest("/app.js", mfs.ModTime(), "")
+func ConditionalRequest(target string, modSince time.Time, etag string) *http.Request {
+	r, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		panic("modembed: invalid request target: " + err.Error())
+	}
+	if !modSince.IsZero() {
+		r.Header.Set("If-Modified-Since", modSince.UTC().Format(http.TimeFormat))
+	}
+	if etag != "" {
+		r.Header.Set("If-None-Match", etag)
+	}
+	return r
+}
+
+// responseRecorder 是记录状态码与消息体长度的最小 http.ResponseWriter
+// 不使用 httptest 以免在非测试代码中注册它的命令行参数
+type responseRecorder struct {
+	header      http.Header
+	code        int
+	body        int
+	wroteHeader bool
+}
+
+func (rr *responseRecorder) Header() http.Header { return rr.header }
+func (rr *responseRecorder) WriteHeader(code int) {
+	if !rr.wroteHeader && code >= 200 {
+		rr.code, rr.wroteHeader = code, true
+	}
+}
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	rr.WriteHeader(http.StatusOK)
+	rr.body += len(p)
+	return len(p), nil
+}
+
+func serveRecorded(h http.Handler, method, urlPath string, header http.Header) *responseRecorder {
+	r, err := http.NewRequest(method, urlPath, nil)
+	if err != nil {
+		return &responseRecorder{header: http.Header{}, code: http.StatusBadRequest}
+	}
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	rec := &responseRecorder{header: http.Header{}, code: http.StatusOK}
+	h.ServeHTTP(rec, r)
+	return rec
+}
@@ -0,0 +1,43 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckConditional(t *testing.T) {
+	lastModified := testModTime.UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+		etag   string
+		want   int
+		done   bool
+	}{
+		{"no conditions", http.MethodGet, nil, `"v1"`, http.StatusOK, false},
+		{"If-None-Match", http.MethodGet, http.Header{"If-None-Match": {`"v1"`}}, `"v1"`, http.StatusNotModified, true},
+		{"If-None-Match star", http.MethodGet, http.Header{"If-None-Match": {"*"}}, `"v1"`, http.StatusNotModified, true},
+		{"If-None-Match star without ETag", http.MethodGet, http.Header{"If-None-Match": {"*"}}, "", http.StatusNotModified, true},
+		{"If-None-Match star on POST", http.MethodPost, http.Header{"If-None-Match": {"*"}}, "", http.StatusPreconditionFailed, true},
+		{"If-Match star without ETag", http.MethodGet, http.Header{"If-Match": {"*"}}, "", http.StatusOK, false},
+		{"mismatched If-Match", http.MethodGet, http.Header{"If-Match": {`"v2"`}}, `"v1"`, http.StatusPreconditionFailed, true},
+		{"If-Match without ETag", http.MethodGet, http.Header{"If-Match": {`"v1"`}}, "", http.StatusPreconditionFailed, true},
+		{"If-Modified-Since", http.MethodGet, http.Header{"If-Modified-Since": {lastModified}}, "", http.StatusNotModified, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.header {
+				r.Header[k] = v
+			}
+			status, done := CheckConditional(r, testModTime, tt.etag)
+			if status != tt.want || done != tt.done {
+				t.Errorf("CheckConditional = %d, %v; want %d, %v", status, done, tt.want, tt.done)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package modembed
+
+import (
+	"bytes"
+	"io/fs"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ModTimeExtractor 从文件内容中读取修改时间, 没有找到时返回 false
+// 例如静态站点生成器在页面中留下的 <!-- lastmod: 2024-05-01 --> 或 front matter 中的 lastmod
+type ModTimeExtractor func(name string, data []byte) (time.Time, bool)
+
+type contentTimeRule struct {
+	extract  ModTimeExtractor
+	patterns []string
+}
+
+// contentTime 是一个文件从内容中读取到的修改时间的缓存, 零值表示没有找到
+type contentTime struct {
+	t time.Time
+}
+
+// WithContentModTime 对匹配 patterns 的文件使用 extract 从内容中读取的修改时间
+// 在 WithModTimeFunc 之后, WithModTimeMap 之前检查, 没有找到时按其余规则继续查找
+// 每个底层文件只在第一次需要它的修改时间时读取一次并缓存结果; 虚拟文件每次读取它的内容
+// 读取的是转换之前的原始内容; patterns 为空时匹配所有文件
+//
+//	modembed.New(content, modembed.WithBuildTime(), modembed.WithContentModTime(modembed.HTMLCommentModTime, "*.html"))
+func WithContentModTime(extract ModTimeExtractor, patterns ...string) Option {
+	return func(o *options) {
+		o.contentTime = &contentTimeRule{extract: extract, patterns: patterns}
+	}
+}
+
+// contentModTime 返回 name 的内容中记录的修改时间, 不适用或没有找到时返回零值
+func (mfs *ModTimeFS) contentModTime(name string, vf *virtualFile) time.Time {
+	rule := mfs.contentTime
+	if rule == nil || (len(rule.patterns) > 0 && !matchAny(rule.patterns, name)) {
+		return time.Time{}
+	}
+	if vf != nil {
+		if t, ok := rule.extract(name, vf.data); ok {
+			return mfs.normTime(t)
+		}
+		return time.Time{}
+	}
+	if v, ok := mfs.contentTimes.Load(name); ok {
+		return v.(*contentTime).t
+	}
+	var ct contentTime
+	if data, err := fs.ReadFile(mfs.FS, name); err == nil {
+		if t, ok := rule.extract(name, data); ok {
+			ct.t = mfs.normTime(t)
+		}
+	}
+	mfs.contentTimes.Store(name, &ct)
+	return ct.t
+}
+
+// contentTimeLayouts 是内容中的时间可以使用的格式, 不带时区时按 UTC 解析
+var contentTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseContentTime 按 RFC 3339 或 2006-01-02 [15:04[:05]] 的格式解析时间, 可以带引号
+// 供自定义的 ModTimeExtractor 使用
+func ParseContentTime(s string) (time.Time, bool) {
+	s = strings.Trim(strings.TrimSpace(s), `"'`)
+	for _, layout := range contentTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+var htmlLastmod = regexp.MustCompile(`<!--\s*lastmod\s*:\s*(.*?)\s*-->`)
+
+// HTMLCommentModTime 读取 HTML 中第一个 <!-- lastmod: ... --> 注释记录的时间
+func HTMLCommentModTime(name string, data []byte) (time.Time, bool) {
+	m := htmlLastmod.FindSubmatch(data)
+	if m == nil {
+		return time.Time{}, false
+	}
+	return ParseContentTime(string(m[1]))
+}
+
+// FrontMatterModTime 读取文件开头 YAML front matter (以 --- 开始与结束) 中的 lastmod, 没有时使用 date
+func FrontMatterModTime(name string, data []byte) (time.Time, bool) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	rest, ok := bytes.CutPrefix(data, []byte("---"))
+	if !ok {
+		return time.Time{}, false
+	}
+	var date string
+	for i, line := range strings.Split(string(rest), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if i == 0 {
+			if strings.TrimSpace(line) != "" {
+				return time.Time{}, false
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "---" {
+			break
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "lastmod":
+			return ParseContentTime(value)
+		case "date":
+			date = value
+		}
+	}
+	if date == "" {
+		return time.Time{}, false
+	}
+	return ParseContentTime(date)
+}
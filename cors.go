@@ -0,0 +1,91 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig 配置跨域资源共享 (CORS), 用于向其他来源提供字体, WASM 等资源
+type CORSConfig struct {
+	AllowedOrigins   []string      // 允许的来源, 例如 "https://app.example.com"; "*" 表示任意来源
+	AllowedMethods   []string      // 预检请求允许的方法, 为空时为 GET, HEAD
+	AllowedHeaders   []string      // 预检请求允许的请求头, 为空时回显预检请求中的 Access-Control-Request-Headers
+	ExposedHeaders   []string      // 允许脚本读取的响应头, 例如 ETag, Content-Length
+	MaxAge           time.Duration // 预检结果的缓存时间, 为 0 时不设置
+	AllowCredentials bool          // 是否允许携带凭据; 只对明确列出的来源生效, 经由 "*" 允许的来源总是不带凭据
+}
+
+// WithCORS 为 Handler 启用 CORS
+// 来自允许来源的请求会得到 Access-Control-Allow-Origin 等响应头, OPTIONS 预检请求直接以 204 响应
+func WithCORS(cfg CORSConfig) HandlerOption {
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = []string{http.MethodGet, http.MethodHead}
+	}
+	return func(h *handler) {
+		h.cors = &cfg
+	}
+}
+
+// allowOrigin 返回对 origin 使用的 Access-Control-Allow-Origin 值以及是否允许携带凭据, 不允许时返回空字符串
+// 明确列出的来源优先于 "*"; "*" 不会回显来源, 否则任意网站都可以带凭据读取响应
+func (c *CORSConfig) allowOrigin(origin string) (string, bool) {
+	wildcard := false
+	for _, allowed := range c.AllowedOrigins {
+		switch {
+		case allowed == "*":
+			wildcard = true
+		case strings.EqualFold(allowed, origin):
+			return origin, c.AllowCredentials
+		}
+	}
+	if wildcard {
+		return "*", false
+	}
+	return "", false
+}
+
+// handleCORS 写出 CORS 响应头, 返回请求是否为已经处理完毕的预检请求
+func (h *handler) handleCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if h.cors == nil || origin == "" {
+		return false
+	}
+	header := w.Header()
+	addVary(header, "Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	allowed, credentials := h.cors.allowOrigin(origin)
+	if allowed == "" {
+		if preflight {
+			w.WriteHeader(http.StatusForbidden)
+		}
+		return preflight
+	}
+	header.Set("Access-Control-Allow-Origin", allowed)
+	if credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if !preflight {
+		if len(h.cors.ExposedHeaders) > 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(h.cors.ExposedHeaders, ", "))
+		}
+		return false
+	}
+
+	addVary(header, "Access-Control-Request-Method")
+	addVary(header, "Access-Control-Request-Headers")
+	header.Set("Access-Control-Allow-Methods", strings.Join(h.cors.AllowedMethods, ", "))
+	if len(h.cors.AllowedHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(h.cors.AllowedHeaders, ", "))
+	} else if reqHeaders := r.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", reqHeaders)
+	}
+	if h.cors.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(h.cors.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
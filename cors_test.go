@@ -0,0 +1,40 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCORSWildcardWithCredentials(t *testing.T) {
+	mfs := New(fstest.MapFS{"font.woff2": {Data: []byte("font")}}, WithModTime(time.Unix(1e9, 0)))
+	h := Handler(mfs, WithCORS(CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "*"},
+		AllowCredentials: true,
+	}))
+
+	tests := []struct {
+		origin      string
+		allowOrigin string
+		credentials string
+	}{
+		{"https://app.example.com", "https://app.example.com", "true"},
+		{"https://evil.example", "*", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/font.woff2", nil)
+		r.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tt.origin, got, tt.allowOrigin)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+			t.Errorf("%s: Access-Control-Allow-Credentials = %q, want %q", tt.origin, got, tt.credentials)
+		}
+	}
+}
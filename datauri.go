@@ -0,0 +1,55 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// dataURIEntry 缓存一个文件的 data: URI, 失效规则同 digestEntry
+type dataURIEntry struct {
+	stamp cacheStamp
+	n     int // 内容的字节数
+	value string
+}
+
+// DataURI 返回 name 对应文件的 base64 data: URI, 例如 "data:image/png;base64,..."
+// 用于内联小的图片与字体以减少请求; 内容 (包括转换的结果) 超过 maxSize 字节时返回错误, maxSize <= 0 表示不限制
+// MIME 类型按扩展名确定, 扩展名未知时根据内容嗅探; 结果会被缓存
+func (mfs *ModTimeFS) DataURI(name string, maxSize int) (string, error) {
+	name = cleanPath(name)
+	stamp, info, err := mfs.stampOf(name)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", &fs.PathError{Op: "datauri", Path: name, Err: ErrIsDirectory}
+	}
+	v, ok := mfs.dataURIs.Load(name)
+	e, _ := v.(*dataURIEntry)
+	if !ok || !e.stamp.matches(stamp) {
+		data, err := mfs.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		ctype := mime.TypeByExtension(path.Ext(name))
+		if ctype == "" {
+			ctype = http.DetectContentType(data)
+		}
+		// data: URI 的媒体类型中不允许空白, 例如 "text/css; charset=utf-8" -> "text/css;charset=utf-8"
+		ctype = strings.ReplaceAll(ctype, " ", "")
+		e = &dataURIEntry{stamp: stamp, n: len(data)}
+		e.value = "data:" + ctype + ";base64," + base64.StdEncoding.EncodeToString(data)
+		mfs.dataURIs.Store(name, e)
+	}
+	if maxSize > 0 && e.n > maxSize {
+		return "", fmt.Errorf("modembed: %s is %d bytes, exceeds data URI limit of %d", name, e.n, maxSize)
+	}
+	return e.value, nil
+}
@@ -0,0 +1,238 @@
+package modembed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Decoder 返回解压 r 的 io.ReadCloser, 例如 GzipDecoder
+type Decoder func(r io.Reader) (io.ReadCloser, error)
+
+// GzipDecoder 是 gzip 格式的 Decoder
+func GzipDecoder(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// WithDecompression 让以 suffix 结尾的压缩文件 (例如 app.js.gz) 同时以去掉后缀的名称 (app.js) 出现
+// 访问时使用 decode 透明解压, 用于以压缩形式嵌入资源以减小二进制体积
+// 解压结果缓存在内存中, 总大小不超过 cacheBytes (LRU 淘汰), cacheBytes <= 0 时不缓存内容
+// 同名的未压缩文件优先; 压缩文件本身仍然可见, 因此可以与 WithPrecompressed 配合直接提供给接受该编码的客户端
+// 其他格式 (例如 zstd) 可以通过自定义的 Decoder 接入; 多次调用时按注册顺序检查
+func WithDecompression(suffix string, decode Decoder, cacheBytes int64) Option {
+	return func(o *options) {
+		o.decoders = append(o.decoders, decoderRule{suffix: suffix, decode: decode, cacheBytes: cacheBytes})
+	}
+}
+
+type decoderRule struct {
+	suffix     string
+	decode     Decoder
+	cacheBytes int64
+}
+
+// decompressFS 在底层文件系统之上按后缀提供解压后的文件
+type decompressFS struct {
+	fsys  fs.FS
+	rules []decoderRule
+	cache *lruCache[string, []byte] // 逻辑路径 -> 解压后的内容
+	sizes sync.Map                  // 逻辑路径 -> 解压后的大小, 使 Stat 在缓存淘汰后无需再次解压
+}
+
+func newDecompressFS(fsys fs.FS, rules []decoderRule) *decompressFS {
+	var cacheBytes int64
+	for _, rule := range rules {
+		cacheBytes += max(rule.cacheBytes, 0)
+	}
+	dfs := &decompressFS{fsys: fsys, rules: rules}
+	if cacheBytes > 0 {
+		dfs.cache = newLRU[string, []byte](cacheBytes)
+	}
+	return dfs
+}
+
+// blob 返回逻辑路径 name 对应的压缩文件及其解码方式
+func (dfs *decompressFS) blob(name string) (string, *decoderRule, fs.FileInfo, bool) {
+	for i := range dfs.rules {
+		rule := &dfs.rules[i]
+		info, err := fs.Stat(dfs.fsys, name+rule.suffix)
+		if err == nil && !info.IsDir() {
+			return name + rule.suffix, rule, info, true
+		}
+	}
+	return "", nil, nil, false
+}
+
+// decompressed 返回 name 解压后的内容
+func (dfs *decompressFS) decompressed(name, blob string, rule *decoderRule) ([]byte, error) {
+	if dfs.cache != nil {
+		if data, ok := dfs.cache.get(name); ok {
+			return data, nil
+		}
+	}
+	f, err := dfs.fsys.Open(blob)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rc, err := rule.decode(f)
+	if err != nil {
+		return nil, &fs.PathError{Op: "decompress", Path: blob, Err: err}
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, &fs.PathError{Op: "decompress", Path: blob, Err: err}
+	}
+	dfs.sizes.Store(name, int64(len(data)))
+	if dfs.cache != nil {
+		dfs.cache.add(name, data, int64(len(data)))
+	}
+	return data, nil
+}
+
+func (dfs *decompressFS) Open(name string) (fs.File, error) {
+	f, err := dfs.fsys.Open(name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		if err == nil && isDirFile(f) {
+			// 目录的条目需要合并解压后的名称
+			return &decompressDir{File: f, dfs: dfs, name: name}, nil
+		}
+		return f, err
+	}
+	blob, rule, info, ok := dfs.blob(name)
+	if !ok {
+		return nil, err
+	}
+	data, err := dfs.decompressed(name, blob, rule)
+	if err != nil {
+		return nil, err
+	}
+	return &decompressedFile{Reader: bytes.NewReader(data), info: &decompressedInfo{FileInfo: info, name: path.Base(name), size: int64(len(data))}}, nil
+}
+
+func isDirFile(f fs.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.IsDir()
+}
+
+func (dfs *decompressFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(dfs.fsys, name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return info, err
+	}
+	blob, rule, binfo, ok := dfs.blob(name)
+	if !ok {
+		return nil, err
+	}
+	size, ok := dfs.sizes.Load(name)
+	if !ok {
+		data, err := dfs.decompressed(name, blob, rule)
+		if err != nil {
+			return nil, err
+		}
+		size = int64(len(data))
+	}
+	return &decompressedInfo{FileInfo: binfo, name: path.Base(name), size: size.(int64)}, nil
+}
+
+func (dfs *decompressFS) ReadFile(name string) ([]byte, error) {
+	data, err := fs.ReadFile(dfs.fsys, name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return data, err
+	}
+	blob, rule, _, ok := dfs.blob(name)
+	if !ok {
+		return nil, err
+	}
+	data, err = dfs.decompressed(name, blob, rule)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(data), nil
+}
+
+// ReadDir 在目录的条目中加入压缩文件对应的解压后名称
+// 这些条目的 Info 在调用时才解压以得到大小
+func (dfs *decompressFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(dfs.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		present[entry.Name()] = true
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		for _, rule := range dfs.rules {
+			if base, ok := strings.CutSuffix(entry.Name(), rule.suffix); ok && base != "" && !present[base] {
+				present[base] = true
+				entries = append(entries, &decompressedEntry{dfs: dfs, name: path.Join(name, base)})
+				break
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// decompressDir 是目录文件, ReadDir 返回合并后的条目
+type decompressDir struct {
+	fs.File
+	dfs  *decompressFS
+	name string
+
+	entries []fs.DirEntry
+	loaded  bool
+	offset  int
+}
+
+func (dd *decompressDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !dd.loaded {
+		entries, err := dd.dfs.ReadDir(dd.name)
+		if err != nil {
+			return nil, err
+		}
+		dd.entries, dd.loaded = entries, true
+	}
+	return readDirPage(dd.entries, &dd.offset, count)
+}
+
+// decompressedFile 是解压后的内存文件
+type decompressedFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *decompressedFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *decompressedFile) Close() error               { return nil }
+
+// decompressedInfo 以解压后的名称与大小报告压缩文件的信息
+type decompressedInfo struct {
+	fs.FileInfo
+	name string
+	size int64
+}
+
+func (di *decompressedInfo) Name() string { return di.name }
+func (di *decompressedInfo) Size() int64  { return di.size }
+
+// decompressedEntry 是解压后文件的目录条目
+type decompressedEntry struct {
+	dfs  *decompressFS
+	name string
+}
+
+func (de *decompressedEntry) Name() string               { return path.Base(de.name) }
+func (de *decompressedEntry) IsDir() bool                { return false }
+func (de *decompressedEntry) Type() fs.FileMode          { return 0 }
+func (de *decompressedEntry) Info() (fs.FileInfo, error) { return de.dfs.Stat(de.name) }
@@ -0,0 +1,29 @@
+package modembed
+
+import (
+	"encoding/hex"
+)
+
+// WithDedupe 让内容相同的文件 (例如放在多个目录下的同一份字体或图标) 共享内存中的表示
+// 启用后 Handler 的压缩结果按内容哈希缓存, 每份内容只压缩并缓存一次;
+// Materialize 对内容相同的文件只保留一份副本, 并在 MaterializeStats.Shared 中报告
+// 按内容缓存需要先计算文件的 SHA-256 (与强 ETag 共用同一个缓存); 可以用 Analyze 查看树中有多少重复内容
+func WithDedupe() Option {
+	return func(o *options) {
+		o.dedupe = true
+	}
+}
+
+// contentKey 返回 name 在可共享缓存中的键, 启用 WithDedupe 时内容相同的文件得到相同的键
+// ok 表示键是否由内容哈希得到; 未启用或无法计算哈希时返回 name 本身
+func (mfs *ModTimeFS) contentKey(name string) (key string, ok bool) {
+	if !mfs.dedupe {
+		return name, false
+	}
+	sum, err := mfs.digest(name)
+	if err != nil {
+		return name, false
+	}
+	// 路径中不会出现 NUL, 内容键不会与路径冲突
+	return "\x00sha256:" + hex.EncodeToString(sum[:]), true
+}
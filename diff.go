@@ -0,0 +1,115 @@
+package modembed
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Change 描述两个清单之间内容发生变化的文件
+type Change struct {
+	Path      string
+	OldSize   int64
+	NewSize   int64
+	OldSHA256 string
+	NewSHA256 string
+}
+
+// SizeDelta 返回文件大小的变化量
+func (c Change) SizeDelta() int64 { return c.NewSize - c.OldSize }
+
+// Report 是 Diff 的结果, 各列表按路径排序
+type Report struct {
+	Added   []Entry
+	Removed []Entry
+	Changed []Change
+}
+
+// Diff 比较清单 a (旧) 与 b (新), 返回新增, 删除与内容变化的文件
+// 两边都记录了哈希时按哈希判断内容是否变化, 否则按大小判断; 只有修改时间不同的文件不算变化
+func Diff(a, b Manifest) Report {
+	old := make(map[string]Entry, len(a))
+	for _, e := range a {
+		old[cleanPath(e.Path)] = e
+	}
+	var r Report
+	seen := make(map[string]bool, len(b))
+	for _, e := range b {
+		name := cleanPath(e.Path)
+		seen[name] = true
+		o, ok := old[name]
+		if !ok {
+			r.Added = append(r.Added, e)
+			continue
+		}
+		changed := o.Size != e.Size
+		if o.SHA256 != "" && e.SHA256 != "" {
+			changed = o.SHA256 != e.SHA256
+		}
+		if changed {
+			r.Changed = append(r.Changed, Change{Path: name, OldSize: o.Size, NewSize: e.Size, OldSHA256: o.SHA256, NewSHA256: e.SHA256})
+		}
+	}
+	for name, e := range old {
+		if !seen[name] {
+			r.Removed = append(r.Removed, e)
+		}
+	}
+	sort.Slice(r.Added, func(i, j int) bool { return r.Added[i].Path < r.Added[j].Path })
+	sort.Slice(r.Removed, func(i, j int) bool { return r.Removed[i].Path < r.Removed[j].Path })
+	sort.Slice(r.Changed, func(i, j int) bool { return r.Changed[i].Path < r.Changed[j].Path })
+	return r
+}
+
+// Empty 判断两个清单是否没有差异
+func (r Report) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// SizeDelta 返回总大小的变化量
+func (r Report) SizeDelta() int64 {
+	var delta int64
+	for _, e := range r.Added {
+		delta += e.Size
+	}
+	for _, e := range r.Removed {
+		delta -= e.Size
+	}
+	for _, c := range r.Changed {
+		delta += c.SizeDelta()
+	}
+	return delta
+}
+
+// WriteText 以每个文件一行的文本格式写出报告, 最后一行是汇总
+// 新增, 删除与变化的文件分别以 "+", "-", "~" 开头, 例如 "~ index.html (+12 bytes, 3f9a2c1b -> 8e4d0a77)"
+func (r Report) WriteText(w io.Writer) error {
+	for _, e := range r.Added {
+		if _, err := fmt.Fprintf(w, "+ %s (%d bytes)\n", e.Path, e.Size); err != nil {
+			return err
+		}
+	}
+	for _, e := range r.Removed {
+		if _, err := fmt.Fprintf(w, "- %s (%d bytes)\n", e.Path, e.Size); err != nil {
+			return err
+		}
+	}
+	for _, c := range r.Changed {
+		detail := fmt.Sprintf("%+d bytes", c.SizeDelta())
+		if c.OldSHA256 != "" && c.NewSHA256 != "" {
+			detail += ", " + shortHash(c.OldSHA256) + " -> " + shortHash(c.NewSHA256)
+		}
+		if _, err := fmt.Fprintf(w, "~ %s (%s)\n", c.Path, detail); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d changed, %+d bytes\n", len(r.Added), len(r.Removed), len(r.Changed), r.SizeDelta())
+	return err
+}
+
+func shortHash(sum string) string {
+	if len(sum) > fingerprintLen {
+		return sum[:fingerprintLen]
+	}
+	return sum
+}
@@ -0,0 +1,53 @@
+package modembed
+
+import (
+	"io/fs"
+)
+
+// WithDirCache 在创建时遍历一次整个目录树并缓存包装后的目录条目
+// 之后的 ReadDir 与 fs.WalkDir 直接返回缓存的切片, 不再产生分配
+// 只适用于内容不会变化的底层文件系统 (例如 embed.FS); 包含虚拟文件的目录不使用缓存
+// 返回的切片在多次调用之间共享, 调用方不得修改
+func WithDirCache() Option {
+	return func(o *options) {
+		o.dirCache = true
+	}
+}
+
+// buildDirCache 遍历目录树, 记录每个目录包装后的条目
+// 无法读取的目录不进入缓存, 之后的调用照常访问底层文件系统
+func (mfs *ModTimeFS) buildDirCache() {
+	cache := make(map[string][]fs.DirEntry)
+	var walk func(dir string)
+	walk = func(dir string) {
+		entries, err := mfs.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		cache[dir] = entries
+		for _, entry := range entries {
+			if entry.IsDir() {
+				walk(joinPath(dir, entry.Name()))
+			}
+		}
+	}
+	walk(".")
+	mfs.dirCache = cache
+}
+
+// cachedDir 返回目录 name 缓存的条目
+func (mfs *ModTimeFS) cachedDir(name string) ([]fs.DirEntry, bool) {
+	if mfs.dirCache == nil || mfs.virtualChildren(name) != nil {
+		return nil, false
+	}
+	entries, ok := mfs.dirCache[name]
+	return entries, ok
+}
+
+// joinPath 与 path.Join 相同, 但对已经清理过的路径避免多余的处理
+func joinPath(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return dir + "/" + name
+}
@@ -0,0 +1,147 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WithEarlyHints 为页面声明关键资源, 提供这些页面时加上 Link: rel=preload 响应头并先发送 103 Early Hints
+// hints 的键为页面路径 (例如 index.html), 值为资源路径, 都是文件系统中的路径; 可以由 ScanPreloads 生成
+// 资源的 URL 为 prefix+路径, 设置了 WithFingerprints 时使用带指纹的 URL; 文件系统中不存在的资源被忽略
+func WithEarlyHints(prefix string, hints map[string][]string) HandlerOption {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	cleaned := make(map[string][]string, len(hints))
+	for page, assets := range hints {
+		for _, asset := range assets {
+			cleaned[cleanPath(page)] = append(cleaned[cleanPath(page)], cleanPath(asset))
+		}
+	}
+	return func(h *handler) {
+		h.earlyHints = &earlyHints{prefix: prefix, hints: cleaned}
+	}
+}
+
+type earlyHints struct {
+	prefix string
+	hints  map[string][]string
+
+	once  sync.Once
+	links map[string][]string // 页面 -> Link 头的值
+}
+
+// preloadAs 按扩展名返回 Link 头中的 as 参数, 字体需要 crossorigin 才能被复用
+func preloadAs(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".css":
+		return "style"
+	case ".js", ".mjs":
+		return "script"
+	case ".woff", ".woff2", ".ttf", ".otf":
+		return "font; crossorigin"
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg", ".ico":
+		return "image"
+	case ".json":
+		return "fetch; crossorigin"
+	}
+	return ""
+}
+
+// linksFor 返回页面 name 的 Link 头, 第一次调用时校验资源并构建全部页面的结果
+func (h *handler) linksFor(name string) []string {
+	eh := h.earlyHints
+	eh.once.Do(func() {
+		eh.links = make(map[string][]string, len(eh.hints))
+		for page, assets := range eh.hints {
+			for _, asset := range assets {
+				if info, err := h.fsys.Stat(asset); err != nil || info.IsDir() {
+					continue
+				}
+				url := eh.prefix + asset
+				if h.fingerprints != nil {
+					url = h.fingerprints.AssetPath(asset)
+				}
+				link := "<" + url + ">; rel=preload"
+				if as := preloadAs(asset); as != "" {
+					link += "; as=" + as
+				}
+				eh.links[page] = append(eh.links[page], link)
+			}
+		}
+	})
+	return eh.links[name]
+}
+
+// sendEarlyHints 为页面 name 写出 Link 头, 对 HTTP/1.1 及以上的 GET 请求先发送 103
+func (h *handler) sendEarlyHints(w http.ResponseWriter, r *http.Request, name string) {
+	if h.earlyHints == nil {
+		return
+	}
+	links := h.linksFor(name)
+	if len(links) == 0 {
+		return
+	}
+	for _, link := range links {
+		w.Header().Add("Link", link)
+	}
+	if r.Method == http.MethodGet && r.ProtoAtLeast(1, 1) {
+		w.WriteHeader(http.StatusEarlyHints)
+	}
+}
+
+// preloadRe 匹配 rel 属性值中 ScanPreloads 收集的链接类型 preload, modulepreload 与 stylesheet
+var preloadRe = regexp.MustCompile(`(?i)\b(preload|modulepreload|stylesheet)\b`)
+
+// ScanPreloads 扫描匹配 patterns (默认 *.html) 的页面中的 <link rel=preload|modulepreload|stylesheet href=...>
+// 返回可直接用于 WithEarlyHints 的映射; 以 prefix 开头的 href 去掉 prefix, 相对 href 相对于页面所在目录解析
+// 外部 URL 与文件系统中不存在的资源被忽略
+func ScanPreloads(fsys *ModTimeFS, prefix string, patterns ...string) (map[string][]string, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"*.html"}
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	hints := make(map[string][]string)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !matchAny(patterns, name) {
+			return err
+		}
+		data, err := fsys.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool)
+		for _, tag := range linkTag.FindAll(data, -1) {
+			rel := linkRel.FindSubmatch(tag)
+			href := linkHref.FindSubmatch(tag)
+			if rel == nil || href == nil || !preloadRe.Match(rel[1]) {
+				continue
+			}
+			asset, ok := hrefToName(string(bytes.TrimSpace(href[1])), prefix, path.Dir(name))
+			if !ok || seen[asset] {
+				continue
+			}
+			if info, err := fsys.Stat(asset); err != nil || info.IsDir() {
+				continue
+			}
+			seen[asset] = true
+			hints[name] = append(hints[name], asset)
+		}
+		sort.Strings(hints[name])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hints, nil
+}
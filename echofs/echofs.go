@@ -0,0 +1,56 @@
+// Package echofs 以 echo 的方式使用 modembed.Handler
+//
+//	h := echofs.Handler(mfs, modembed.WithPrecompressed())
+//	e.GET("/static/*", h)
+//	e.HEAD("/static/*", h)
+//
+// 与 echo 自带的 Static 中间件不同, 响应保留 ModTimeFS 设定的修改时间以及 modembed 的 ETag, 预压缩等特性
+package echofs
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wjqserver/modembed"
+)
+
+// Handler 返回提供 mfs 的 echo.HandlerFunc, opts 传给 modembed.Handler
+// 路由的通配参数 (例如 "/static/*") 作为文件路径, 没有通配参数时使用完整的请求路径
+func Handler(mfs *modembed.ModTimeFS, opts ...modembed.HandlerOption) echo.HandlerFunc {
+	h := modembed.Handler(mfs, opts...)
+	return func(c echo.Context) error {
+		r := c.Request()
+		if p := c.Param("*"); p != "" || hasWildcard(c.ParamNames()) {
+			if r.URL.RawPath != "" {
+				// 请求路径含有转义字符时 echo 按原始路径匹配, 参数值仍是转义后的形式
+				if unescaped, err := url.PathUnescape(p); err == nil {
+					p = unescaped
+				}
+			}
+			r = withPath(r, "/"+p)
+		}
+		h.ServeHTTP(c.Response(), r)
+		return nil
+	}
+}
+
+func hasWildcard(names []string) bool {
+	for _, name := range names {
+		if name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// withPath 返回 URL 路径替换为 p 的请求副本, 与 http.StripPrefix 相同
+func withPath(r *http.Request, p string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = p
+	r2.URL.RawPath = ""
+	return r2
+}
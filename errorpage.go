@@ -0,0 +1,61 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"strconv"
+)
+
+// WithErrorPage 使用文件系统中的 name 作为状态码 code 的错误页, 例如 WithErrorPage(404, "errors/404.html")
+// 错误页以原状态码返回, Content-Type 按 name 的扩展名决定, 并带有 Cache-Control: no-cache
+// 错误页本身无法读取时回退到默认的纯文本响应
+func WithErrorPage(code int, name string) HandlerOption {
+	name = cleanPath(name)
+	return func(h *handler) {
+		if h.errorPages == nil {
+			h.errorPages = make(map[int]string)
+		}
+		h.errorPages[code] = name
+	}
+}
+
+// serveError 将文件系统错误映射为对应的 HTTP 状态码
+func (h *handler) serveError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		h.serveStatus(w, r, http.StatusNotFound, "404 page not found")
+	case errors.Is(err, fs.ErrPermission):
+		h.serveStatus(w, r, http.StatusForbidden, "403 Forbidden")
+	case r.Context().Err() != nil && errors.Is(err, r.Context().Err()):
+		// 请求在等待内容转换或压缩时被取消或超时
+		h.serveStatus(w, r, http.StatusServiceUnavailable, "503 Service Unavailable")
+	default:
+		h.serveStatus(w, r, http.StatusInternalServerError, "500 Internal Server Error")
+	}
+}
+
+// serveStatus 写出状态码 code 的错误响应, 配置了错误页时使用错误页, 否则使用纯文本 text
+func (h *handler) serveStatus(w http.ResponseWriter, r *http.Request, code int, text string) {
+	if name, ok := h.errorPages[code]; ok {
+		if data, err := h.fsys.ReadFile(name); err == nil {
+			header := w.Header()
+			// 清除为原请求设置的缓存相关头, 错误页不应被长期缓存
+			header.Del("ETag")
+			header.Del("Last-Modified")
+			header.Del("Content-Encoding")
+			header.Set("Cache-Control", "no-cache")
+			header.Set("Content-Type", h.contentType(name))
+			header.Set("Content-Length", strconv.Itoa(len(data)))
+			header.Set("X-Content-Type-Options", "nosniff")
+			w.WriteHeader(code)
+			if r.Method != http.MethodHead {
+				w.Write(data)
+			}
+			return
+		}
+	}
+	http.Error(w, text, code)
+}
@@ -0,0 +1,15 @@
+package modembed
+
+import "errors"
+
+// 可以用 errors.Is 判断的错误值, 返回时通常包装在带有路径的 fs.PathError 中
+var (
+	// ErrNotSeekable 表示文件既不支持随机访问 (Seek 或 ReadAt), 也无法读入内存
+	ErrNotSeekable = errors.New("modembed: file is not seekable")
+	// ErrNotDirectory 表示对不是目录的文件执行了目录操作
+	ErrNotDirectory = errors.New("modembed: file is not a directory")
+	// ErrIsDirectory 表示对目录执行了只适用于普通文件的操作
+	ErrIsDirectory = errors.New("modembed: file is a directory")
+	// ErrZeroModTime 表示在 ZeroTimeError 策略下统一修改时间为零值
+	ErrZeroModTime = errors.New("modembed: zero modification time")
+)
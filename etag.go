@@ -0,0 +1,139 @@
+package modembed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// cacheStamp 记录缓存值计算时文件的大小, 底层修改时间以及所属的虚拟文件
+// 底层文件变化 (例如开发模式下的磁盘文件) 或虚拟文件被重新注册时缓存失效, 即使大小与修改时间都没有变化
+type cacheStamp struct {
+	size    int64
+	modTime time.Time
+	vf      *virtualFile
+}
+
+// stampOf 返回 name 当前的 cacheStamp 与未经包装的 FileInfo
+// 必须在读取内容之前取得, 这样与并发的 AddVirtual 竞争时缓存值只会对应更旧的 stamp, 不会被当作新内容的结果
+func (mfs *ModTimeFS) stampOf(name string) (cacheStamp, fs.FileInfo, error) {
+	if vf := mfs.virtualFileOf(name); vf != nil {
+		return cacheStamp{size: vf.fi.Size(), modTime: vf.fi.ModTime(), vf: vf}, vf.fi, nil
+	}
+	info, err := mfs.rawStat(name)
+	if err != nil {
+		return cacheStamp{}, nil, err
+	}
+	return cacheStamp{size: info.Size(), modTime: info.ModTime()}, info, nil
+}
+
+func (s cacheStamp) matches(o cacheStamp) bool {
+	return s.size == o.size && s.modTime.Equal(o.modTime) && s.vf == o.vf
+}
+
+// digestEntry 缓存一个文件的内容哈希
+type digestEntry struct {
+	stamp cacheStamp
+	sum   [sha256.Size]byte
+}
+
+// digest 返回 name 对应文件内容的 SHA-256, 结果会被缓存
+func (mfs *ModTimeFS) digest(name string) ([sha256.Size]byte, error) {
+	name = cleanPath(name)
+	stamp, info, err := mfs.stampOf(name)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	if info.IsDir() {
+		return [sha256.Size]byte{}, &fs.PathError{Op: "digest", Path: name, Err: ErrIsDirectory}
+	}
+	if v, ok := mfs.digests.Load(name); ok {
+		if e := v.(*digestEntry); e.stamp.matches(stamp) {
+			return e.sum, nil
+		}
+	}
+
+	// 哈希针对实际提供的内容, 有转换时使用转换后的结果
+	f, err := mfs.Open(name)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	e := &digestEntry{stamp: stamp}
+	h.Sum(e.sum[:0])
+	mfs.digests.Store(name, e)
+	return e.sum, nil
+}
+
+// weakETagRule 是 WithWeakETags 的设定
+type weakETagRule struct {
+	minSize  int64
+	patterns []string // 为空表示所有文件
+}
+
+// WithWeakETags 对匹配 patterns (为空时为所有文件) 且不小于 minSize 字节的文件使用弱 ETag W/"<修改时间>-<大小>"
+// 弱 ETag 不需要读取内容计算哈希, 适合很大的文件; 其余文件仍使用由内容哈希派生的强 ETag
+// 弱 ETag 只用于 If-None-Match 的弱比较, If-Match 与 If-Range 不会命中, 带 If-Range 的范围请求会得到完整内容
+func WithWeakETags(minSize int64, patterns ...string) Option {
+	return func(o *options) {
+		o.weakETags = &weakETagRule{minSize: minSize, patterns: patterns}
+	}
+}
+
+// weakETag 判断 name 是否使用弱 ETag, 是时返回它
+func (mfs *ModTimeFS) weakETag(name string) (string, bool) {
+	rule := mfs.weakETags
+	if rule == nil || (len(rule.patterns) > 0 && !matchAny(rule.patterns, name)) {
+		return "", false
+	}
+	info, err := mfs.Stat(name)
+	if err != nil || info.IsDir() || info.Size() < rule.minSize {
+		return "", false
+	}
+	return fmt.Sprintf(`W/"%x-%x"`, info.ModTime().Unix(), info.Size()), true
+}
+
+// variantETag 返回预压缩变体 variant 的 ETag, 原始文件 name 使用弱 ETag 时变体也使用弱 ETag
+func (mfs *ModTimeFS) variantETag(name, variant string) (string, bool) {
+	if _, weak := mfs.weakETag(name); weak {
+		if info, err := mfs.Stat(variant); err == nil && !info.IsDir() {
+			return fmt.Sprintf(`W/"%x-%x"`, info.ModTime().Unix(), info.Size()), true
+		}
+	}
+	return mfs.strongETag(variant)
+}
+
+// ETag 返回 name 对应文件的 ETag (带引号)
+// 默认是由内容的 SHA-256 派生的强 ETag, 哈希在首次访问时计算并缓存; WithWeakETags 匹配的文件使用弱 ETag
+// 文件不存在或为目录时返回 false
+func (mfs *ModTimeFS) ETag(name string) (string, bool) {
+	if etag, ok := mfs.weakETag(cleanPath(name)); ok {
+		return etag, true
+	}
+	return mfs.strongETag(name)
+}
+
+func (mfs *ModTimeFS) strongETag(name string) (string, bool) {
+	sum, err := mfs.digest(name)
+	if err != nil {
+		return "", false
+	}
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, true
+}
+
+// SHA256 返回 name 对应文件内容 (包括转换的结果) 的 SHA-256 十六进制字符串, 与 Manifest 中的值一致
+// 哈希与 ETag 共用同一个缓存
+func (mfs *ModTimeFS) SHA256(name string) (string, error) {
+	sum, err := mfs.digest(name)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum[:]), nil
+}
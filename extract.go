@@ -0,0 +1,137 @@
+package modembed
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ExtractPolicy 决定 ExtractTo 遇到已存在的目标文件时的处理方式
+type ExtractPolicy int
+
+const (
+	ExtractOverwrite    ExtractPolicy = iota // 总是覆盖
+	ExtractSkipExisting                      // 目标已存在时跳过
+	ExtractSkipSameHash                      // 内容相同时跳过写入 (仍会更新修改时间), 否则覆盖
+)
+
+// ExtractTo 将文件树写入磁盘目录 dir, 保留目录结构, 并通过 os.Chtimes 应用设定的修改时间
+// patterns 非空时只写出匹配的文件; 文件先写入临时文件再重命名, 因此只读的旧文件也可以被覆盖
+func (mfs *ModTimeFS) ExtractTo(dir string, policy ExtractPolicy, patterns ...string) error {
+	type dirTime struct {
+		path string
+		info fs.FileInfo
+	}
+	var dirs []dirTime
+	err := fs.WalkDir(mfs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			dirs = append(dirs, dirTime{target, info})
+			return nil
+		}
+		if !info.Mode().IsRegular() || (len(patterns) > 0 && !matchAny(patterns, name)) {
+			return nil
+		}
+		return mfs.extractFile(name, target, info, policy)
+	})
+	if err != nil {
+		return err
+	}
+	// 写入文件会改变目录的修改时间, 因此最后从深到浅设置目录时间
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if t := dirs[i].info.ModTime(); !t.IsZero() {
+			if err := os.Chtimes(dirs[i].path, t, t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (mfs *ModTimeFS) extractFile(name, target string, info fs.FileInfo, policy ExtractPolicy) error {
+	switch policy {
+	case ExtractSkipExisting:
+		if _, err := os.Lstat(target); err == nil {
+			return nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	case ExtractSkipSameHash:
+		same, err := mfs.sameContent(name, target)
+		if err != nil {
+			return err
+		}
+		if same {
+			return chtimes(target, info)
+		}
+	}
+
+	src, err := mfs.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".modembed-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // 重命名成功后为无操作
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := chtimes(tmp.Name(), info); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// sameContent 判断磁盘上的 target 是否与 name 的内容相同, target 不存在时返回 false
+func (mfs *ModTimeFS) sameContent(name, target string) (bool, error) {
+	f, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, err
+	}
+	want, err := mfs.digest(name)
+	if err != nil {
+		return false, err
+	}
+	var got [sha256.Size]byte
+	h.Sum(got[:0])
+	return got == want, nil
+}
+
+func chtimes(target string, info fs.FileInfo) error {
+	t := info.ModTime()
+	if t.IsZero() {
+		return nil
+	}
+	return os.Chtimes(target, t, t)
+}
@@ -0,0 +1,53 @@
+// Package fiberfs 以 fiber 的方式使用 modembed.Handler
+//
+//	app.All("/static/*", fiberfs.Handler(mfs, modembed.WithPrecompressed()))
+//
+// 与 fiber 自带的 Static 不同, 响应保留 ModTimeFS 设定的修改时间以及 modembed 的 ETag, 预压缩等特性
+// 请求通过 adaptor 转换为 net/http 请求后交给 modembed.Handler, 非 GET 与 HEAD 请求得到 405
+package fiberfs
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/wjqserver/modembed"
+)
+
+// Handler 返回提供 mfs 的 fiber.Handler, opts 传给 modembed.Handler
+// 路由的通配参数 (例如 "/static/*") 作为文件路径, 没有通配参数时使用完整的请求路径
+func Handler(mfs *modembed.ModTimeFS, opts ...modembed.HandlerOption) fiber.Handler {
+	h := modembed.Handler(mfs, opts...)
+	whole := adaptor.HTTPHandler(h)
+	return func(c *fiber.Ctx) error {
+		if !hasWildcard(c.Route().Params) {
+			return whole(c)
+		}
+		// fiber 的参数值是未解码的原始路径
+		p := c.Params("*")
+		if unescaped, err := url.PathUnescape(p); err == nil {
+			p = unescaped
+		}
+		p = "/" + p
+		if orig, _, _ := strings.Cut(c.OriginalURL(), "?"); strings.HasSuffix(orig, "/") && !strings.HasSuffix(p, "/") {
+			// 非严格路由下 fiber 会去掉参数末尾的 "/", 保留它以免目录的重定向形成循环
+			p += "/"
+		}
+		return adaptor.HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.URL.Path = p
+			r.URL.RawPath = ""
+			h.ServeHTTP(w, r)
+		})(c)
+	}
+}
+
+func hasWildcard(names []string) bool {
+	for _, name := range names {
+		if name == "*" || name == "*1" {
+			return true
+		}
+	}
+	return false
+}
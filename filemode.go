@@ -0,0 +1,31 @@
+package modembed
+
+import (
+	"io/fs"
+)
+
+// fileModeRule 是 WithFileMode 的一条规则
+type fileModeRule struct {
+	glob string
+	perm fs.FileMode
+}
+
+// WithFileMode 让匹配 glob 的文件与目录在 Stat, ReadDir 与 Open 返回的 FileInfo 中报告权限位 mode
+// (embed.FS 总是报告 0444 或目录的 0555), 例如用 WithFileMode("scripts/*.sh", 0o755) 让 ExtractTo 写出可执行的脚本
+// 只替换权限位, 文件类型不变; ExtractTo, WriteTar 与 WriteZip 使用替换后的权限
+// glob 的语法同 ModTimeRule.Glob; 可以多次使用, 按添加顺序第一个匹配的规则生效
+func WithFileMode(glob string, mode fs.FileMode) Option {
+	return func(o *options) {
+		o.fileModes = append(o.fileModes, fileModeRule{glob: glob, perm: mode.Perm()})
+	}
+}
+
+// fileMode 返回 name 在应用 WithFileMode 之后的模式
+func (mfs *ModTimeFS) fileMode(name string, mode fs.FileMode) fs.FileMode {
+	for _, rule := range mfs.fileModes {
+		if matchPattern(rule.glob, name) {
+			return mode&^fs.ModePerm | rule.perm
+		}
+	}
+	return mode
+}
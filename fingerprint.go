@@ -0,0 +1,198 @@
+package modembed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// fingerprintLen 是指纹中使用的哈希十六进制字符数
+const fingerprintLen = 8
+
+// immutableCacheControl 用于内容寻址 (带指纹) 的路径
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// Fingerprints 记录文件的内容指纹路径 (如 app.3f9a2c1b.js) 与原始路径之间的映射
+// 创建后只读, 可以被多个 goroutine 并发使用
+type Fingerprints struct {
+	fsys   *ModTimeFS
+	prefix string // 对外提供服务时的 URL 前缀, 总是以 "/" 结尾
+
+	byName   map[string]string // 原始路径 -> 指纹路径
+	byHashed map[string]string // 指纹路径 -> 原始路径
+
+	rewritten map[string]*rewrittenAsset // 重写引用后的文件内容, 见 Rewrite
+}
+
+type rewrittenAsset struct {
+	data []byte
+	etag string
+}
+
+// NewFingerprints 遍历 fsys 中的所有文件并计算指纹路径
+// prefix 是这些文件对外提供服务时的 URL 前缀 (例如 "/static/"), AssetPath 返回的路径以它开头
+func NewFingerprints(fsys *ModTimeFS, prefix string) (*Fingerprints, error) {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	fp := &Fingerprints{
+		fsys:     fsys,
+		prefix:   prefix,
+		byName:   make(map[string]string),
+		byHashed: make(map[string]string),
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		sum, err := fsys.digest(name)
+		if err != nil {
+			return err
+		}
+		fp.set(name, sum[:])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fp, nil
+}
+
+func (fp *Fingerprints) set(name string, sum []byte) {
+	if old, ok := fp.byName[name]; ok {
+		delete(fp.byHashed, old)
+	}
+	hashed := fingerprintName(name, hex.EncodeToString(sum)[:fingerprintLen])
+	fp.byName[name] = hashed
+	fp.byHashed[hashed] = name
+}
+
+// fingerprintName 将哈希插入到扩展名之前, 例如 js/app.min.js -> js/app.min.3f9a2c1b.js
+func fingerprintName(name, hash string) string {
+	dir, base := path.Split(name)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	if stem == "" {
+		// 形如 .htaccess 的文件没有可用的主名
+		return dir + base + "." + hash
+	}
+	return dir + stem + "." + hash + ext
+}
+
+// AssetPath 返回 name 对应的带指纹的 URL, 例如 AssetPath("app.js") -> "/static/app.3f9a2c1b.js"
+// name 不存在时返回未加指纹的 URL
+func (fp *Fingerprints) AssetPath(name string) string {
+	name = cleanPath(name)
+	if hashed, ok := fp.byName[name]; ok {
+		return fp.prefix + hashed
+	}
+	return fp.prefix + name
+}
+
+// Resolve 将指纹路径还原为原始路径
+func (fp *Fingerprints) Resolve(hashed string) (string, bool) {
+	name, ok := fp.byHashed[cleanPath(hashed)]
+	return name, ok
+}
+
+// Rewrite 在启动时重写匹配 patterns (默认 *.html 与 *.css) 的文件中对其他文件的引用
+// 形如 prefix+原始路径 的引用会被替换为对应的指纹 URL, Handler 随后提供重写后的内容
+// 被重写文件的指纹基于重写后的内容计算, 因此引用的资源变化时引用方的指纹也会变化
+// Rewrite 必须在开始提供服务之前调用
+func (fp *Fingerprints) Rewrite(patterns ...string) error {
+	if len(patterns) == 0 {
+		patterns = []string{"*.html", "*.css"}
+	}
+	originals := make(map[string][]byte)
+	for name := range fp.byName {
+		for _, pattern := range patterns {
+			if matchPattern(pattern, name) {
+				data, err := fs.ReadFile(fp.fsys, name)
+				if err != nil {
+					return err
+				}
+				originals[name] = data
+				break
+			}
+		}
+	}
+
+	// 被重写的文件之间也可能相互引用 (例如 CSS 的 @import)
+	// 反复重写直到指纹不再变化, 对无环的引用关系这会收敛
+	fp.rewritten = make(map[string]*rewrittenAsset, len(originals))
+	for round := 0; round <= len(originals); round++ {
+		changed := false
+		for name, data := range originals {
+			out := fp.rewriteRefs(data)
+			sum := sha256.Sum256(out)
+			if old := fp.rewritten[name]; old == nil || !bytes.Equal(old.data, out) {
+				changed = true
+			}
+			fp.rewritten[name] = &rewrittenAsset{data: out, etag: `"` + hex.EncodeToString(sum[:16]) + `"`}
+			fp.set(name, sum[:])
+		}
+		if !changed {
+			break
+		}
+	}
+	return nil
+}
+
+// rewrittenFor 返回 name 重写后的内容, 没有重写时返回 nil
+func (fp *Fingerprints) rewrittenFor(name string) *rewrittenAsset {
+	if fp == nil {
+		return nil
+	}
+	return fp.rewritten[name]
+}
+
+// rewriteRefs 替换 data 中所有指向已知文件的 prefix+路径 引用
+func (fp *Fingerprints) rewriteRefs(data []byte) []byte {
+	prefix := []byte(fp.prefix)
+	var out bytes.Buffer
+	last := 0
+	for i := 0; i < len(data); {
+		j := bytes.Index(data[i:], prefix)
+		if j < 0 {
+			break
+		}
+		start := i + j
+		refStart := start + len(prefix)
+		if start > 0 && isRefChar(data[start-1]) {
+			// 前缀出现在另一个路径中间, 例如相对路径 foo/app.js
+			i = refStart
+			continue
+		}
+		end := refStart
+		for end < len(data) && isRefChar(data[end]) {
+			end++
+		}
+		if hashed, ok := fp.byName[string(data[refStart:end])]; ok {
+			out.Write(data[last:refStart])
+			out.WriteString(hashed)
+			last = end
+		}
+		i = end
+		if i == refStart {
+			i++
+		}
+	}
+	if last == 0 {
+		return data
+	}
+	out.Write(data[last:])
+	return out.Bytes()
+}
+
+// isRefChar 判断 c 是否可能是引用路径的一部分
+// 引号, 括号, 空白, 查询串与片段分隔符等都会终止路径
+func isRefChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-._~/%@+!$&*=:", c) >= 0
+}
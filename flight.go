@@ -0,0 +1,75 @@
+package modembed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// flightGroup 合并对同一个 key 的并发计算: 一个 goroutine 执行, 其余等待并共享结果
+// 用于即时压缩与转换, 避免冷启动时大量并发请求重复做同样的工作; 零值可以直接使用
+// 计算在独立的 goroutine 中进行, 不受某一个调用方的 ctx 影响; 所有等待的调用方都因 ctx 结束而离开时计算被取消
+type flightGroup[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*flightCall[V]
+}
+
+type flightCall[V any] struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int // 仍在等待结果的调用方数, 由 flightGroup.mu 保护
+	val     V
+	err     error
+}
+
+// do 执行 fn 并返回其结果, 同一个 key 已经有计算在进行时等待它完成
+// ctx 结束时立即返回 ctx.Err(); fn 收到的 context 在所有调用方都离开后被取消
+func (g *flightGroup[K, V]) do(ctx context.Context, key K, fn func(context.Context) (V, error)) (V, error) {
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if !ok {
+		if g.calls == nil {
+			g.calls = make(map[K]*flightCall[V])
+		}
+		workCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &flightCall[V]{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(workCtx, key, c, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// 没有调用方需要这个结果了, 取消计算; 之后的调用方重新开始
+			c.cancel()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (g *flightGroup[K, V]) run(ctx context.Context, key K, c *flightCall[V], fn func(context.Context) (V, error)) {
+	defer func() {
+		if p := recover(); p != nil {
+			c.err = fmt.Errorf("modembed: panic during concurrent computation: %v", p)
+		}
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		c.cancel()
+		close(c.done)
+	}()
+	c.val, c.err = fn(ctx)
+}
@@ -0,0 +1,88 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"html/template"
+	"path"
+	"strings"
+	"time"
+)
+
+// FuncMap 返回一组与 mfs 绑定的模板函数, 可以直接传给 ParseTemplates 或 template.Funcs
+//
+//	AssetPath "app.js"     带指纹的 URL, fp 为 nil 时返回 "/app.js"
+//	SRIAttr "app.js"       integrity 属性, 同 ModTimeFS.SRIAttr
+//	InlineAsset "logo.svg" 文件内容, 按扩展名标记为 template.HTML / CSS / JS, 用于内联小的 SVG 与样式
+//	AssetModTime "app.js"  文件的修改时间, 可以用来标记构建时间
+//	DataURI "icon.png" 4096 data: URI, 同 ModTimeFS.DataURI, 用于 src 与 url() 属性
+//
+// 被 fp 重写过的文件内联时使用重写后的内容, SRIAttr 也针对重写后的内容计算
+func (mfs *ModTimeFS) FuncMap(fp *Fingerprints) template.FuncMap {
+	return template.FuncMap{
+		"AssetPath": func(name string) string {
+			if fp == nil {
+				return "/" + cleanPath(name)
+			}
+			return fp.AssetPath(name)
+		},
+		"SRIAttr": func(name string) template.HTMLAttr {
+			// Handler 提供的是重写后的内容, integrity 必须与之一致
+			if ra := fp.rewrittenFor(cleanPath(name)); ra != nil {
+				return template.HTMLAttr(`integrity="` + sriValue(ra.data) + `"`)
+			}
+			return mfs.SRIAttr(name)
+		},
+		"InlineAsset": func(name string) (any, error) {
+			name = cleanPath(name)
+			var data []byte
+			if ra := fp.rewrittenFor(name); ra != nil {
+				data = ra.data
+			} else {
+				var err error
+				if data, err = mfs.ReadFile(name); err != nil {
+					return nil, err
+				}
+			}
+			return inlineContent(name, string(data)), nil
+		},
+		"AssetModTime": func(name string) (time.Time, error) {
+			info, err := mfs.Stat(cleanPath(name))
+			if err != nil {
+				return time.Time{}, err
+			}
+			return info.ModTime(), nil
+		},
+		"DataURI": func(name string, maxSize int) (template.URL, error) {
+			uri, err := mfs.DataURI(name, maxSize)
+			return template.URL(uri), err
+		},
+	}
+}
+
+// inlineContent 按扩展名将内容标记为对应上下文中的安全值
+// 内容来自嵌入的文件, 由程序作者控制, 因此不再转义
+func inlineContent(name, data string) any {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".svg", ".html", ".htm":
+		return template.HTML(data)
+	case ".css":
+		return template.CSS(data)
+	case ".js", ".mjs":
+		return template.JS(data)
+	}
+	return data
+}
+
+// SRIAttr 返回可直接写入 HTML 标签的 integrity 属性, 例如
+//
+//	<script src="/static/js/app.js" {{sri "js/app.js"}}></script>
+//
+// 配合 template.FuncMap{"sri": mfs.SRIAttr} 使用; 文件不存在时返回空属性
+func (mfs *ModTimeFS) SRIAttr(name string) template.HTMLAttr {
+	value, err := mfs.SRI(name)
+	if err != nil {
+		return ""
+	}
+	return template.HTMLAttr(`integrity="` + value + `"`)
+}
@@ -0,0 +1,46 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFuncMapSRIAttrRewritten(t *testing.T) {
+	mfs := New(fstest.MapFS{
+		"site.css": {Data: []byte(`body { background: url(/static/bg.png) }`)},
+		"bg.png":   {Data: []byte("png")},
+		"app.js":   {Data: []byte("console.log(1)")},
+	}, WithModTime(testModTime))
+	fp, err := NewFingerprints(mfs, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fp.Rewrite(); err != nil {
+		t.Fatal(err)
+	}
+	h := http.StripPrefix("/static", Handler(mfs, WithFingerprints(fp)))
+	tmpl := template.Must(template.New("").Funcs(mfs.FuncMap(fp)).Parse(`<link {{SRIAttr .}}>`))
+
+	for _, name := range []string{"site.css", "app.js"} {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, name); err != nil {
+			t.Fatal(err)
+		}
+		w := serveTest(h, http.MethodGet, fp.AssetPath(name), nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d", fp.AssetPath(name), w.Code)
+		}
+		sum := sha512.Sum384(w.Body.Bytes())
+		want := `<link integrity="sha384-` + base64.StdEncoding.EncodeToString(sum[:]) + `">`
+		if b.String() != want {
+			t.Errorf("SRIAttr %s = %s, want %s (hash of the served body %q)", name, b.String(), want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Package ginfs 以 gin 的方式使用 modembed.Handler
+//
+//	h := ginfs.Handler(mfs, modembed.WithPrecompressed())
+//	r.GET("/static/*filepath", h)
+//	r.HEAD("/static/*filepath", h)
+//
+// 与 gin 自带的 Static 系列不同, 响应保留 ModTimeFS 设定的修改时间以及 modembed 的 ETag, 预压缩等特性
+package ginfs
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wjqserver/modembed"
+)
+
+// Handler 返回提供 mfs 的 gin.HandlerFunc, opts 传给 modembed.Handler
+// 路由中名为 filepath 的通配参数 (例如 "/static/*filepath") 作为文件路径, 没有该参数时使用完整的请求路径
+func Handler(mfs *modembed.ModTimeFS, opts ...modembed.HandlerOption) gin.HandlerFunc {
+	h := modembed.Handler(mfs, opts...)
+	return func(c *gin.Context) {
+		r := c.Request
+		if p := c.Param("filepath"); p != "" {
+			r = withPath(r, p)
+		}
+		h.ServeHTTP(c.Writer, r)
+		c.Abort()
+	}
+}
+
+// Static 在 routes 的 prefix 下注册 GET 与 HEAD 路由, 等价于 gin 的 StaticFS
+func Static(routes gin.IRoutes, prefix string, mfs *modembed.ModTimeFS, opts ...modembed.HandlerOption) {
+	h := Handler(mfs, opts...)
+	pattern := prefix + "/*filepath"
+	if prefix != "" && prefix[len(prefix)-1] == '/' {
+		pattern = prefix + "*filepath"
+	}
+	routes.GET(pattern, h)
+	routes.HEAD(pattern, h)
+}
+
+// withPath 返回 URL 路径替换为 p 的请求副本, 与 http.StripPrefix 相同
+func withPath(r *http.Request, p string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = p
+	r2.URL.RawPath = ""
+	return r2
+}
@@ -0,0 +1,292 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Handler 返回一个基于 ModTimeFS 提供静态文件的 http.Handler
+// 响应带有 Last-Modified 以及由内容哈希派生的强 ETag, 支持 GET 与 HEAD
+// 条件请求遵循 RFC 9110: If-None-Match 使用弱比较 (W/"..." 也能命中), If-Match 与 If-Range 使用强比较
+// If-None-Match 存在时忽略 If-Modified-Since; 命中时返回不含消息体的 304
+// 可以用 CheckNotModified 在测试中验证这些行为
+func Handler(fsys *ModTimeFS, opts ...HandlerOption) http.Handler {
+	h := &handler{fsys: fsys, indexFiles: []string{indexPage}, maxRanges: defaultMaxRanges, invalidPathStatus: http.StatusNotFound}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.fingerprints != nil && !h.cachePolicySet {
+		h.cachePolicy = DefaultCachePolicy()
+	}
+	return h
+}
+
+// ServeFile 返回只提供 name 这一个文件的 http.Handler, 适用于 favicon.ico, robots.txt, app.webmanifest 等
+// 无论请求路径如何都提供该文件, 不经过路由, 目录索引与 SPA 回退; 修改时间, ETag, Content-Type 与缓存策略同 Handler
+// opts 与 Handler 相同, 与单个文件无关的选项 (例如 WithSPAFallback) 没有效果
+func ServeFile(fsys *ModTimeFS, name string, opts ...HandlerOption) http.Handler {
+	h := Handler(fsys, opts...).(*handler)
+	h.file = cleanPath(name)
+	return h
+}
+
+// HandlerOption 用于配置 Handler
+type HandlerOption func(*handler)
+
+type handler struct {
+	fsys        *ModTimeFS
+	encodings   []string // 预压缩变体的编码偏好顺序, 为空表示不启用
+	variants    *variantIndex
+	compression *compressor // 即时压缩, 为 nil 表示不启用
+
+	cachePolicy    CachePolicy
+	cachePolicySet bool // 是否通过 WithCachePolicy 显式设置, 否则启用指纹时使用 DefaultCachePolicy
+	fingerprints   *Fingerprints
+	spaIndex       string // 单页应用的回退页面, 为空表示不启用
+	autoIndex      bool
+	autoIndexSort  AutoIndexSort
+	autoIndexDesc  bool
+	indexFiles     []string // 目录的索引文件, 为空表示不提供索引页
+	trailingSlash  TrailingSlash
+	maxRanges      int               // 单个请求允许的最大范围数, 小于 0 表示不限制
+	contentTypes   map[string]string // 扩展名 (小写, 带 ".") -> Content-Type
+	localize       *localizer
+	images         *imageVariants
+	earlyHints     *earlyHints
+
+	deny              []string
+	invalidPathStatus int // 可疑请求路径的状态码, 0 表示不检查
+	securityHeaders   http.Header
+	headers           []headerRule // 按路径附加的响应头
+
+	routes     map[string]http.Handler // 由选项注册的虚拟路径, 优先于文件系统中的文件
+	errorPages map[int]string          // 状态码 -> 错误页路径
+
+	cors      *CORSConfig
+	authorize func(r *http.Request, name string) bool
+	authRealm string
+
+	signer         *URLSigner
+	signedPatterns []string
+
+	throttles []throttleRule // WithBandwidthLimit 的限额
+
+	collector Collector
+	logf      func(LogEntry)
+	tracer    Tracer
+
+	file string // ServeFile 固定提供的文件, 为空表示按请求路径查找
+}
+
+const indexPage = "index.html"
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.collector != nil || h.logf != nil || h.tracer != nil {
+		sw := &statusWriter{ResponseWriter: w}
+		defer h.observe(sw, r, h.fsys.now())
+		w = sw
+		if h.tracer != nil {
+			var span TraceSpan
+			r, span = h.traceRequest(r)
+			defer h.endTrace(span, sw, r)
+		}
+	}
+	for k, v := range h.securityHeaders {
+		w.Header()[k] = v
+	}
+	if h.handleCORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		h.serveStatus(w, r, http.StatusMethodNotAllowed, "405 method not allowed")
+		return
+	}
+	if h.rejectPath(w, r) {
+		return
+	}
+	r = h.limitRanges(r)
+	if h.file != "" {
+		if h.authorized(w, r, h.file) {
+			if err := h.serveName(w, r, h.file); err != nil {
+				h.serveError(w, r, err)
+			}
+		}
+		return
+	}
+	upath := r.URL.Path
+	if !strings.HasPrefix(upath, "/") {
+		upath = "/" + upath
+	}
+	if !h.authorized(w, r, cleanPath(upath)) {
+		return
+	}
+	if rh, ok := h.routes[cleanPath(upath)]; ok {
+		rh.ServeHTTP(w, r)
+		return
+	}
+	err := h.serveFile(w, r, upath)
+	if err != nil && h.spaIndex != "" && errors.Is(err, fs.ErrNotExist) && path.Ext(upath) == "" {
+		err = h.serveName(w, r, h.spaIndex)
+	}
+	if err != nil {
+		h.serveError(w, r, err)
+	}
+}
+
+// route 在 name 上注册一个虚拟路径
+func (h *handler) route(name string, rh http.Handler) {
+	if h.routes == nil {
+		h.routes = make(map[string]http.Handler)
+	}
+	h.routes[name] = rh
+}
+
+// serveFile 提供 upath 对应的文件或目录索引
+// 在写出任何响应之前发生的文件系统错误会被返回, 由调用方决定如何响应
+func (h *handler) serveFile(w http.ResponseWriter, r *http.Request, upath string) error {
+	// 与 http.FileServer 一致, 将 .../index.html 重定向到 .../
+	if h.indexRedirect(upath) {
+		localRedirect(w, r, "./")
+		return nil
+	}
+
+	name := cleanPath(upath)
+	if h.denied(name) {
+		return errDenied(name)
+	}
+	if h.fingerprints != nil {
+		if orig, ok := h.fingerprints.Resolve(name); ok {
+			name = orig
+			w.Header().Set("Cache-Control", immutableCacheControl)
+		}
+	}
+	name = h.localized(w, r, name)
+	name = h.imageVariant(w, r, name)
+	f, err := h.open(r, name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return h.serveDir(w, r, name, upath)
+	}
+	if strings.HasSuffix(upath, "/") && upath != "/" {
+		localRedirect(w, r, "../"+path.Base(upath))
+		return nil
+	}
+	h.serveContent(w, r, name, f, info)
+	return nil
+}
+
+// serveName 打开并提供 name 对应的普通文件
+func (h *handler) serveName(w http.ResponseWriter, r *http.Request, name string) error {
+	if h.denied(name) {
+		return errDenied(name)
+	}
+	name = h.localized(w, r, name)
+	name = h.imageVariant(w, r, name)
+	f, err := h.open(r, name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	h.serveContent(w, r, name, f, info)
+	return nil
+}
+
+// serveContent 写出 name 的内容, 若启用了预压缩则优先选择客户端可接受的压缩变体
+func (h *handler) serveContent(w http.ResponseWriter, r *http.Request, name string, f fs.File, info fs.FileInfo) {
+	h.sendEarlyHints(w, r, name)
+	if len(h.throttles) > 0 {
+		w = h.throttle(w, r, name)
+	}
+	if w.Header().Get("Cache-Control") == "" {
+		if cc := h.cachePolicy.CacheControl(name); cc != "" {
+			w.Header().Set("Cache-Control", cc)
+		}
+	}
+	if ctype, ok := h.overrideContentType(name); ok {
+		w.Header().Set("Content-Type", ctype)
+	}
+	h.applyHeaders(w, name)
+	if ra := h.fingerprints.rewrittenFor(name); ra != nil {
+		// 重写后的内容与磁盘上的预压缩变体不再一致, 直接提供内存中的版本
+		w.Header().Set("ETag", ra.etag)
+		serveMemory(w, r, info.Name(), info.ModTime(), ra.data)
+		return
+	}
+	// 预压缩变体保存的是转换前的内容, 有转换的文件不使用它们
+	if len(h.encodings) > 0 && !h.fsys.hasTransform(name) {
+		addVary(w.Header(), "Accept-Encoding")
+		if enc, variant, vf, size := h.openPrecompressed(r, name); vf != nil {
+			defer vf.Close()
+			w.Header().Set("Content-Type", h.contentType(name))
+			if etag, ok := h.fsys.variantETag(name, variant); ok {
+				w.Header().Set("ETag", etag)
+			}
+			// ModTime 仍使用原始文件的时间, 保证各表示的 Last-Modified 一致
+			if sw := findStatusWriter(w); sw != nil {
+				sw.precompressed = true
+			}
+			content, done := h.reader(variant, vf)
+			http.ServeContent(&encodingWriter{ResponseWriter: w, encoding: enc, size: size}, r, info.Name(), info.ModTime(), content)
+			done()
+			return
+		}
+	}
+	if h.compression != nil && h.serveCompressed(w, r, name, f, info) {
+		return
+	}
+
+	if etag, ok := h.fsys.ETag(name); ok {
+		w.Header().Set("ETag", etag)
+	}
+	content, done := h.reader(name, f)
+	if mf, ok := f.(*modTimeFile); ok && mf.buf != nil {
+		// Materialize 载入的文件, 虚拟文件与转换结果直接写出内存中的切片
+		w = memoryWriter{w}
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), content)
+	done()
+}
+
+// WithSPAFallback 启用单页应用 (SPA) 模式
+// 不对应任何文件且没有扩展名的路径 (例如 /users/42) 会得到 index 指定的页面, 状态码为 200
+// 带扩展名的路径 (例如缺失的 /app.js) 仍返回 404, 避免把 HTML 当作脚本或样式返回
+// index 为空时使用 index.html
+func WithSPAFallback(index string) HandlerOption {
+	if index == "" {
+		index = indexPage
+	}
+	index = cleanPath(index)
+	return func(h *handler) {
+		h.spaIndex = index
+	}
+}
+
+// localRedirect 返回相对于当前请求路径的重定向, 保留查询参数
+func localRedirect(w http.ResponseWriter, r *http.Request, newPath string) {
+	if q := r.URL.RawQuery; q != "" {
+		newPath += "?" + q
+	}
+	w.Header().Set("Location", newPath)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
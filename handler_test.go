@@ -0,0 +1,123 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testModTime = time.Unix(1e9, 0)
+
+func newTestHandler(opts ...HandlerOption) http.Handler {
+	mfs := New(fstest.MapFS{
+		"index.html": {Data: []byte("<h1>home</h1>")},
+		"app.js":     {Data: []byte("console.log('hello')")},
+	}, WithModTime(testModTime))
+	return Handler(mfs, opts...)
+}
+
+func serveTest(h http.Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerConditional(t *testing.T) {
+	h := newTestHandler()
+	first := serveTest(h, http.MethodGet, "/app.js", nil)
+	if first.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want 200", first.Code)
+	}
+	etag := first.Header().Get("ETag")
+	lastModified := first.Header().Get("Last-Modified")
+	if etag == "" || etag[0] != '"' {
+		t.Fatalf("ETag = %q, want a strong ETag", etag)
+	}
+	if want := testModTime.UTC().Format(http.TimeFormat); lastModified != want {
+		t.Fatalf("Last-Modified = %q, want %q", lastModified, want)
+	}
+
+	earlier := testModTime.Add(-time.Hour).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+		want   int
+	}{
+		{"strong If-None-Match", http.MethodGet, http.Header{"If-None-Match": {etag}}, http.StatusNotModified},
+		{"weak If-None-Match", http.MethodGet, http.Header{"If-None-Match": {"W/" + etag}}, http.StatusNotModified},
+		{"If-None-Match list", http.MethodGet, http.Header{"If-None-Match": {`"other", ` + etag + `, "more"`}}, http.StatusNotModified},
+		{"If-None-Match star", http.MethodGet, http.Header{"If-None-Match": {"*"}}, http.StatusNotModified},
+		{"mismatched If-None-Match", http.MethodGet, http.Header{"If-None-Match": {`"other"`}}, http.StatusOK},
+		{"If-None-Match wins over If-Modified-Since", http.MethodGet, http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {lastModified}}, http.StatusOK},
+		{"If-Modified-Since", http.MethodGet, http.Header{"If-Modified-Since": {lastModified}}, http.StatusNotModified},
+		{"older If-Modified-Since", http.MethodGet, http.Header{"If-Modified-Since": {earlier}}, http.StatusOK},
+		{"HEAD If-None-Match", http.MethodHead, http.Header{"If-None-Match": {etag}}, http.StatusNotModified},
+		{"HEAD If-Modified-Since", http.MethodHead, http.Header{"If-Modified-Since": {lastModified}}, http.StatusNotModified},
+		{"If-Match", http.MethodGet, http.Header{"If-Match": {etag}}, http.StatusOK},
+		{"mismatched If-Match", http.MethodGet, http.Header{"If-Match": {`"other"`}}, http.StatusPreconditionFailed},
+		{"weak If-Match", http.MethodGet, http.Header{"If-Match": {"W/" + etag}}, http.StatusPreconditionFailed},
+		{"If-Unmodified-Since", http.MethodGet, http.Header{"If-Unmodified-Since": {earlier}}, http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveTest(h, tt.method, "/app.js", tt.header)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if w.Code != http.StatusOK && w.Body.Len() > 0 {
+				t.Errorf("%d response has a %d byte body", w.Code, w.Body.Len())
+			}
+			if w.Code == http.StatusNotModified && w.Header().Get("ETag") != etag {
+				t.Errorf("304 ETag = %q, want %q", w.Header().Get("ETag"), etag)
+			}
+		})
+	}
+}
+
+func TestHandlerHead(t *testing.T) {
+	h := newTestHandler()
+	get := serveTest(h, http.MethodGet, "/app.js", nil)
+	head := serveTest(h, http.MethodHead, "/app.js", nil)
+	if head.Code != http.StatusOK {
+		t.Fatalf("HEAD status = %d, want 200", head.Code)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("HEAD response has a %d byte body", head.Body.Len())
+	}
+	for _, key := range []string{"Content-Length", "Content-Type", "ETag", "Last-Modified"} {
+		if got, want := head.Header().Get(key), get.Header().Get(key); got != want {
+			t.Errorf("HEAD %s = %q, want %q as for GET", key, got, want)
+		}
+	}
+}
+
+func TestCheckNotModified(t *testing.T) {
+	h := newTestHandler()
+	for _, target := range []string{"/app.js", "/"} {
+		if err := CheckNotModified(h, target); err != nil {
+			t.Errorf("CheckNotModified(%s) = %v", target, err)
+		}
+	}
+
+	// 无视条件请求头的处理器必须被报告出来
+	plain := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", testModTime.UTC().Format(http.TimeFormat))
+		w.Write([]byte("body"))
+	})
+	if err := CheckNotModified(plain, "/"); err == nil {
+		t.Error("CheckNotModified accepted a handler that ignores If-None-Match")
+	}
+	if err := CheckNotModified(h, "/missing.js"); err == nil {
+		t.Error("CheckNotModified accepted a 404")
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import "net/http"
+
+// headerRule 是 WithHeaders 注册的一条规则
+type headerRule struct {
+	pattern string
+	headers http.Header
+}
+
+// WithHeaders 为匹配 pattern 的文件附加 headers, 例如 CDN 使用的 Surrogate-Key 与 Surrogate-Control
+// 模式语法同 CachePolicy; 多次调用时按注册顺序应用, 同名响应头以后注册的规则为准
+// 这些响应头在缓存策略之后设置, 因此也可以用来覆盖个别文件的 Cache-Control
+func WithHeaders(pattern string, headers http.Header) HandlerOption {
+	rule := headerRule{pattern: pattern, headers: make(http.Header, len(headers))}
+	for k, v := range headers {
+		rule.headers[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+	}
+	return func(h *handler) {
+		h.headers = append(h.headers, rule)
+	}
+}
+
+// applyHeaders 写出与 name 匹配的自定义响应头
+func (h *handler) applyHeaders(w http.ResponseWriter, name string) {
+	for _, rule := range h.headers {
+		if !matchPattern(rule.pattern, name) {
+			continue
+		}
+		for k, v := range rule.headers {
+			w.Header()[k] = v
+		}
+	}
+}
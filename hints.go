@@ -0,0 +1,145 @@
+package modembed
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// PreloadHintsFile 是 Preload 读取的预热顺序文件, 位于文件系统根目录
+// 内容是按优先级排列的路径 JSON 数组, 例如 ["index.html", "app.css", "app.js"], 通常由 modembed-gen -hints 生成
+// 以 . 开头的文件需要使用 //go:embed all:static 才会被嵌入
+const PreloadHintsFile = ".preload-hints.json"
+
+// 页面与样式表中引用资源的标签与属性匹配
+var (
+	linkTag   = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	linkRel   = regexp.MustCompile(`(?is)\brel\s*=\s*["']?([^"'>]+)`)
+	linkHref  = regexp.MustCompile(`(?is)\bhref\s*=\s*["']?([^"'\s>]+)`)
+	srcTag    = regexp.MustCompile(`(?is)<(?:script|img|source)\b[^>]*>`)
+	srcAttr   = regexp.MustCompile(`(?is)\bsrc\s*=\s*["']?([^"'\s>]+)`)
+	cssURL    = regexp.MustCompile(`(?i)url\(\s*["']?([^"')\s]+)`)
+	cssImport = regexp.MustCompile(`(?i)@import\s+["']([^"']+)`)
+	hintRel   = regexp.MustCompile(`(?i)\b(preload|modulepreload|stylesheet|icon)\b`)
+)
+
+// ScanPreloadHints 从入口页面 entries (为空时为 index.html) 出发, 沿页面中的 <link>, <script>, <img>, <source>
+// 与样式表中的 url(), @import 引用广度优先地遍历, 返回按发现顺序排列的路径, 入口页面本身在最前
+// 以 prefix 开头的引用去掉 prefix, 相对引用相对于所在文件的目录解析; 外部 URL 与不存在的文件被忽略
+func ScanPreloadHints(fsys fs.FS, prefix string, entries ...string) ([]string, error) {
+	if len(entries) == 0 {
+		entries = []string{"index.html"}
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	var hints []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if seen[name] {
+			return
+		}
+		if info, err := fs.Stat(fsys, name); err != nil || info.IsDir() {
+			return
+		}
+		seen[name] = true
+		hints = append(hints, name)
+	}
+	for _, entry := range entries {
+		add(cleanPath(entry))
+	}
+	for i := 0; i < len(hints); i++ {
+		name := hints[i]
+		var refs []string
+		switch strings.ToLower(path.Ext(name)) {
+		case ".html", ".htm":
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, err
+			}
+			refs = htmlRefs(data)
+		case ".css":
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, err
+			}
+			refs = cssRefs(data)
+		}
+		for _, ref := range refs {
+			if asset, ok := hrefToName(strings.TrimSpace(ref), prefix, path.Dir(name)); ok {
+				add(asset)
+			}
+		}
+	}
+	return hints, nil
+}
+
+// htmlRefs 按出现顺序返回页面中引用的资源
+func htmlRefs(data []byte) []string {
+	type ref struct {
+		at  int
+		url string
+	}
+	var refs []ref
+	for _, loc := range linkTag.FindAllIndex(data, -1) {
+		tag := data[loc[0]:loc[1]]
+		rel, href := linkRel.FindSubmatch(tag), linkHref.FindSubmatch(tag)
+		if rel != nil && href != nil && hintRel.Match(rel[1]) {
+			refs = append(refs, ref{loc[0], string(href[1])})
+		}
+	}
+	for _, loc := range srcTag.FindAllIndex(data, -1) {
+		if src := srcAttr.FindSubmatch(data[loc[0]:loc[1]]); src != nil {
+			refs = append(refs, ref{loc[0], string(src[1])})
+		}
+	}
+	slices.SortStableFunc(refs, func(a, b ref) int { return a.at - b.at })
+	urls := make([]string, len(refs))
+	for i, r := range refs {
+		urls[i] = r.url
+	}
+	return urls
+}
+
+// cssRefs 返回样式表中 @import 与 url() 引用的资源
+func cssRefs(data []byte) []string {
+	var urls []string
+	for _, m := range cssImport.FindAllSubmatch(data, -1) {
+		urls = append(urls, string(m[1]))
+	}
+	for _, m := range cssURL.FindAllSubmatch(data, -1) {
+		urls = append(urls, string(m[1]))
+	}
+	return urls
+}
+
+// preloadHints 读取 fsys 中的 PreloadHintsFile, 不存在或无法解析时返回 nil
+func preloadHints(fsys fs.FS) []string {
+	data, err := fs.ReadFile(fsys, PreloadHintsFile)
+	if err != nil {
+		return nil
+	}
+	var hints []string
+	if json.Unmarshal(data, &hints) != nil {
+		return nil
+	}
+	return hints
+}
+
+// hrefToName 将页面中的 href 转换为文件系统中的路径
+func hrefToName(href, prefix, dir string) (string, bool) {
+	href, _, _ = strings.Cut(href, "#")
+	href, _, _ = strings.Cut(href, "?")
+	switch {
+	case href == "" || strings.Contains(href, "://") || strings.HasPrefix(href, "//") || strings.HasPrefix(href, "data:"):
+		return "", false
+	case strings.HasPrefix(href, prefix):
+		return cleanPath(strings.TrimPrefix(href, prefix)), true
+	case strings.HasPrefix(href, "/"):
+		return "", false
+	}
+	return cleanPath(path.Join(dir, href)), true
+}
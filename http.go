@@ -0,0 +1,71 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// HTTPFileSystem 返回一个可以直接交给 http.FileServer 使用的 http.FileSystem
+// 返回的文件总是支持 Seek (必要时回退到内存缓冲) 与 Readdir, 因此 Range 请求可以正常工作
+func (mfs *ModTimeFS) HTTPFileSystem() http.FileSystem {
+	return httpFS{mfs: mfs}
+}
+
+type httpFS struct {
+	mfs *ModTimeFS
+}
+
+func (hfs httpFS) Open(name string) (http.File, error) {
+	// http.FileSystem 的路径以 "/" 开头, fs.FS 使用无根路径
+	if name == "/" {
+		name = "."
+	} else {
+		name = strings.TrimPrefix(name, "/")
+	}
+	file, err := hfs.mfs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return httpFile{modTimeFile: file.(*modTimeFile)}, nil
+}
+
+// --- http.File 包装  ---
+type httpFile struct {
+	*modTimeFile
+}
+
+func (hf httpFile) Readdir(count int) ([]fs.FileInfo, error) {
+	var list []fs.FileInfo
+	for {
+		entries, err := hf.ReadDir(count - len(list))
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				// 与 (*os.File).Readdir 一致, 跳过无法获取信息的条目
+				continue
+			}
+			list = append(list, info)
+		}
+		if err != nil {
+			return list, err
+		}
+		if count <= 0 || len(list) >= count {
+			return list, nil
+		}
+	}
+}
+
+var _ http.File = httpFile{}
+
+// HTTPDate 返回统一修改时间按 HTTP 日期格式 (http.TimeFormat) 格式化的结果, 即 Last-Modified 的值
+// 未设定统一修改时间时返回空字符串
+func (mfs *ModTimeFS) HTTPDate() string {
+	t := mfs.ModTime()
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(http.TimeFormat)
+}
@@ -0,0 +1,24 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+// 核心功能 (缓存策略, 指纹) 对应的 Handler 选项, 与 Handler 一起在 modembed_core 构建中排除
+
+// WithCachePolicy 为 Handler 设置按路径匹配的 Cache-Control 策略
+// 设置后 (包括空策略) 不再使用 DefaultCachePolicy
+func WithCachePolicy(p CachePolicy) HandlerOption {
+	return func(h *handler) {
+		h.cachePolicy = p
+		h.cachePolicySet = true
+	}
+}
+
+// WithFingerprints 让 Handler 识别指纹路径
+// 指纹路径会被还原为原始文件提供, 并带有长期不可变的 Cache-Control
+// 没有通过 WithCachePolicy 设置策略时, 未加指纹的路径使用 DefaultCachePolicy
+// 若调用过 Fingerprints.Rewrite, 被重写的文件将提供重写后的内容
+func WithFingerprints(fp *Fingerprints) HandlerOption {
+	return func(h *handler) {
+		h.fingerprints = fp
+	}
+}
@@ -0,0 +1,109 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LocalizedName 返回 name 在语言 lang (小写, 如 "de" 或 "pt-br") 下对应的文件路径
+type LocalizedName func(name, lang string) string
+
+// DefaultLocalizedName 将语言插入到扩展名之前, 例如 docs/index.html 在 de 下为 docs/index.de.html
+func DefaultLocalizedName(name, lang string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + lang + ext
+}
+
+// WithLocalization 根据 Accept-Language 选择本地化的文件, 例如为 de 用户提供 index.de.html 而不是 index.html
+// 依次尝试客户端按 q 值排序的语言, 对 de-AT 这样的标签先尝试完整标签再尝试 de
+// defaultLang 是未本地化的文件所使用的语言 (例如 "en"), 客户端偏好它胜过其他可用语言时提供原文件, 可以为空
+// 找到本地化文件时设置 Content-Language; 对匹配 patterns (默认 *.html) 的路径总是设置 Vary: Accept-Language
+// naming 为 nil 时使用 DefaultLocalizedName
+func WithLocalization(defaultLang string, naming LocalizedName, patterns ...string) HandlerOption {
+	if naming == nil {
+		naming = DefaultLocalizedName
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"*.html"}
+	}
+	return func(h *handler) {
+		h.localize = &localizer{defaultLang: strings.ToLower(defaultLang), naming: naming, patterns: patterns}
+	}
+}
+
+type localizer struct {
+	defaultLang string
+	naming      LocalizedName
+	patterns    []string
+}
+
+// localized 返回 name 对客户端最合适的本地化版本, 没有时返回 name 本身
+func (h *handler) localized(w http.ResponseWriter, r *http.Request, name string) string {
+	if h.localize == nil || !matchAny(h.localize.patterns, name) {
+		return name
+	}
+	addVary(w.Header(), "Accept-Language")
+	for _, lang := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if lang == h.localize.defaultLang {
+			w.Header().Set("Content-Language", lang)
+			return name
+		}
+		candidate := h.localize.naming(name, lang)
+		if candidate == name || h.denied(candidate) {
+			continue
+		}
+		if info, err := h.fsys.Stat(candidate); err == nil && !info.IsDir() {
+			w.Header().Set("Content-Language", lang)
+			return candidate
+		}
+	}
+	return name
+}
+
+// parseAcceptLanguage 按 q 值从高到低返回 Accept-Language 中的语言标签 (小写)
+// 带地区的标签之后紧跟其主语言, 例如 de-AT 展开为 de-at, de; q=0 与 * 被忽略
+func parseAcceptLanguage(header string) []string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	seen := make(map[string]bool)
+	var langs []string
+	add := func(lang string) {
+		if !seen[lang] {
+			seen[lang] = true
+			langs = append(langs, lang)
+		}
+	}
+	for _, c := range candidates {
+		add(c.lang)
+		if base, _, ok := strings.Cut(c.lang, "-"); ok {
+			add(base)
+		}
+	}
+	return langs
+}
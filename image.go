@@ -0,0 +1,83 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// defaultImageFormats 是 WithImageVariants 默认尝试的变体格式, 按服务端偏好顺序
+var defaultImageFormats = []string{".avif", ".webp"}
+
+// WithImageVariants 根据 Accept 为图片选择同名的其他格式变体, 例如客户端接受 image/avif 时用 photo.avif 代替 photo.jpg
+// formats 是按服务端偏好顺序尝试的扩展名, 为空时为 .avif, .webp; 客户端 q 值更高的格式优先
+// 只有 Accept 中明确列出的类型才会被选中, image/* 与 */* 不算, 因为浏览器总是发送它们
+// patterns 选择启用的路径 (例如 "images/*" 只对 images 目录启用), 为空时对所有 .jpg, .jpeg 与 .png 启用
+// 对匹配的路径总是设置 Vary: Accept
+func WithImageVariants(formats []string, patterns ...string) HandlerOption {
+	if len(formats) == 0 {
+		formats = defaultImageFormats
+	}
+	exts := make([]string, len(formats))
+	for i, format := range formats {
+		exts[i] = strings.ToLower(format)
+		if !strings.HasPrefix(exts[i], ".") {
+			exts[i] = "." + exts[i]
+		}
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"*.jpg", "*.jpeg", "*.png"}
+	}
+	return func(h *handler) {
+		h.images = &imageVariants{formats: exts, patterns: patterns}
+	}
+}
+
+type imageVariants struct {
+	formats  []string
+	patterns []string
+}
+
+// imageVariant 返回 name 对客户端最合适的图片格式变体, 没有时返回 name 本身
+func (h *handler) imageVariant(w http.ResponseWriter, r *http.Request, name string) string {
+	if h.images == nil || !matchAny(h.images.patterns, name) {
+		return name
+	}
+	addVary(w.Header(), "Accept")
+	accepted := parseAcceptEncoding(r.Header.Get("Accept"))
+	if len(accepted) == 0 {
+		return name
+	}
+	ext := path.Ext(name)
+	best, bestQ := name, 0.0
+	for _, format := range h.images.formats {
+		if strings.EqualFold(format, ext) {
+			continue
+		}
+		q := accepted[h.imageType(format)]
+		if q <= bestQ {
+			continue
+		}
+		candidate := strings.TrimSuffix(name, ext) + format
+		if h.denied(candidate) {
+			continue
+		}
+		if info, err := h.fsys.Stat(candidate); err == nil && !info.IsDir() {
+			best, bestQ = candidate, q
+		}
+	}
+	return best
+}
+
+// imageType 返回扩展名 ext 对应的 MIME 类型 (小写, 不带参数)
+func (h *handler) imageType(ext string) string {
+	ctype, ok := h.overrideContentType(ext)
+	if !ok {
+		ctype = mime.TypeByExtension(ext)
+	}
+	ctype, _, _ = strings.Cut(ctype, ";")
+	return strings.ToLower(strings.TrimSpace(ctype))
+}
@@ -0,0 +1,74 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// TrailingSlash 决定访问不带末尾 "/" 的目录 (例如 /docs) 时的行为
+type TrailingSlash int
+
+const (
+	// TrailingSlashRedirect 将 /docs 重定向到 /docs/, 与 http.FileServer 一致 (默认)
+	TrailingSlashRedirect TrailingSlash = iota
+	// TrailingSlashServe 在 /docs 直接提供目录的索引页, 不进行重定向
+	// 注意索引页中的相对链接此时相对于上一级目录解析
+	TrailingSlashServe
+)
+
+// WithIndexFiles 设置目录的索引文件, 按顺序查找第一个存在的文件, 默认为 index.html
+// 不传入任何名称时禁用索引页, 访问目录返回 404 (除非启用了 WithAutoIndex)
+func WithIndexFiles(names ...string) HandlerOption {
+	var cleaned []string
+	for _, name := range names {
+		cleaned = append(cleaned, path.Base(cleanPath(name)))
+	}
+	return func(h *handler) {
+		h.indexFiles = cleaned
+	}
+}
+
+// WithTrailingSlash 设置访问不带末尾 "/" 的目录时的行为, 默认为 TrailingSlashRedirect
+func WithTrailingSlash(policy TrailingSlash) HandlerOption {
+	return func(h *handler) {
+		h.trailingSlash = policy
+	}
+}
+
+// indexRedirect 判断 upath 是否以某个索引文件名结尾, 这样的请求会被重定向到所在目录
+func (h *handler) indexRedirect(upath string) bool {
+	for _, index := range h.indexFiles {
+		if strings.HasSuffix(upath, "/"+index) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveDir 提供目录 name 的索引页, 没有索引页时按设置提供目录列表或返回 fs.ErrNotExist
+func (h *handler) serveDir(w http.ResponseWriter, r *http.Request, name, upath string) error {
+	if !strings.HasSuffix(upath, "/") && h.trailingSlash == TrailingSlashRedirect {
+		localRedirect(w, r, path.Base(upath)+"/")
+		return nil
+	}
+	for _, index := range h.indexFiles {
+		err := h.serveName(w, r, path.Join(name, index))
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if !h.autoIndex {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if !strings.HasSuffix(upath, "/") {
+		// 目录列表使用相对链接, 必须在带 "/" 的路径下提供
+		localRedirect(w, r, path.Base(upath)+"/")
+		return nil
+	}
+	return h.serveDirList(w, r, name, upath)
+}
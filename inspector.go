@@ -0,0 +1,234 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxInspectedPaths 是 Inspector 最多单独计数的请求路径数, 之后的新路径不再计数
+// 避免请求大量不存在路径的爬虫让计数表无限增长
+const maxInspectedPaths = 10000
+
+// Inspector 是排查缓存问题的调试页面, 列出每个文件的 ModTime, ETag, Cache-Control,
+// 缓存状态, 可用的预压缩变体与请求计数, 用于定位生产环境中客户端为何得不到 304
+// 它不会自动注册到任何路径, 需要显式挂载 (并且通常应加上访问控制):
+//
+//	h := modembed.Handler(mfs, modembed.WithPrecompressed())
+//	in, _ := modembed.NewInspector(h)
+//	mux.Handle("/static/", http.StripPrefix("/static", h))
+//	mux.Handle("/debug/modembed/", in)
+//
+// 请求 ?format=json 时以 JSON 返回同样的内容
+type Inspector struct {
+	h    *handler
+	next Collector // Handler 原有的收集器
+
+	mu       sync.Mutex
+	counters map[string]*pathCounters
+}
+
+type pathCounters struct {
+	requests, ok, notModified, other uint64
+	bytes                            int64
+	lastStatus                       int
+	lastSeen                         time.Time
+}
+
+// InspectedFile 是 Inspector 中的一行
+type InspectedFile struct {
+	Path         string    `json:"path"`
+	Exists       bool      `json:"exists"` // 为 false 时是不对应文件的请求路径, 例如带指纹的路径或 404
+	Size         int64     `json:"size"`
+	ModTime      time.Time `json:"modTime"`
+	ETag         string    `json:"etag,omitempty"`
+	CacheControl string    `json:"cacheControl,omitempty"`
+	Variants     []string  `json:"variants,omitempty"` // 可用的预压缩编码
+	Compressed   bool      `json:"compressed"`         // 即时压缩的结果是否在缓存中
+	Transformed  bool      `json:"transformed"`
+	Materialized bool      `json:"materialized"`
+	Virtual      bool      `json:"virtual"`
+
+	Requests    uint64    `json:"requests"`
+	OK          uint64    `json:"ok"`          // 200 与 206
+	NotModified uint64    `json:"notModified"` // 304
+	Other       uint64    `json:"other"`
+	Bytes       int64     `json:"bytes"`
+	LastStatus  int       `json:"lastStatus,omitempty"`
+	LastSeen    time.Time `json:"lastSeen,omitzero"`
+}
+
+// NewInspector 为 Handler 返回的 h 创建调试页面, 并开始统计 h 的请求
+// 应在 h 开始处理请求之前调用; h 已有的收集器会继续收到所有指标
+func NewInspector(h http.Handler) (*Inspector, error) {
+	hh, ok := h.(*handler)
+	if !ok {
+		return nil, errors.New("modembed: NewInspector requires a handler returned by Handler")
+	}
+	in := &Inspector{h: hh, next: hh.collector, counters: make(map[string]*pathCounters)}
+	hh.collector = in
+	return in, nil
+}
+
+func (in *Inspector) ObserveRequest(name string, status int, bytes int64, duration time.Duration) {
+	in.mu.Lock()
+	c := in.counters[name]
+	if c == nil && len(in.counters) < maxInspectedPaths {
+		c = new(pathCounters)
+		in.counters[name] = c
+	}
+	if c != nil {
+		c.requests++
+		switch status {
+		case http.StatusOK, http.StatusPartialContent:
+			c.ok++
+		case http.StatusNotModified:
+			c.notModified++
+		default:
+			c.other++
+		}
+		c.bytes += bytes
+		c.lastStatus = status
+		c.lastSeen = in.h.fsys.now()
+	}
+	in.mu.Unlock()
+	if in.next != nil {
+		in.next.ObserveRequest(name, status, bytes, duration)
+	}
+}
+
+func (in *Inspector) ObserveOpen(name string, duration time.Duration, err error) {
+	if in.next != nil {
+		in.next.ObserveOpen(name, duration, err)
+	}
+}
+
+func (in *Inspector) ObserveRead(name string, bytes int64, duration time.Duration) {
+	if in.next != nil {
+		in.next.ObserveRead(name, bytes, duration)
+	}
+}
+
+// Files 返回当前所有文件与被请求过的路径的状态, 按路径排序; 被 WithDeny 拒绝的文件不会列出
+// 计算 ETag 需要读取每个文件的内容 (结果会被缓存)
+func (in *Inspector) Files() ([]InspectedFile, error) {
+	h := in.h
+	var files []InspectedFile
+	seen := make(map[string]bool)
+	err := fs.WalkDir(h.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || h.denied(name) {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f := InspectedFile{
+			Path:         name,
+			Exists:       true,
+			Size:         info.Size(),
+			ModTime:      info.ModTime(),
+			CacheControl: h.cachePolicy.CacheControl(name),
+			Transformed:  h.fsys.hasTransform(name),
+			Materialized: h.fsys.isMaterialized(name),
+			Virtual:      h.fsys.virtualFileOf(name) != nil,
+		}
+		f.ETag, _ = h.fsys.ETag(name)
+		f.Variants = in.variants(name)
+		if h.compression != nil {
+			key, _ := h.fsys.contentKey(name)
+			f.Compressed = h.compression.cache.contains(key + "\x00gzip")
+		}
+		seen[name] = true
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	in.mu.Lock()
+	for name := range in.counters {
+		if !seen[name] && !h.denied(name) {
+			files = append(files, InspectedFile{Path: name})
+		}
+	}
+	for i := range files {
+		if c := in.counters[files[i].Path]; c != nil {
+			f := &files[i]
+			f.Requests, f.OK, f.NotModified, f.Other = c.requests, c.ok, c.notModified, c.other
+			f.Bytes, f.LastStatus, f.LastSeen = c.bytes, c.lastStatus, c.lastSeen
+		}
+	}
+	in.mu.Unlock()
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// variants 返回 name 可用的预压缩编码
+func (in *Inspector) variants(name string) []string {
+	h := in.h
+	if len(h.encodings) == 0 || h.fsys.hasTransform(name) {
+		return nil
+	}
+	index := h.variants.load(h.fsys)
+	var encodings []string
+	for _, enc := range h.encodings {
+		if index != nil {
+			if slices.Contains(index[name], enc) {
+				encodings = append(encodings, enc)
+			}
+			continue
+		}
+		if info, err := h.fsys.Stat(name + encodingSuffix(enc)); err == nil && !info.IsDir() {
+			encodings = append(encodings, enc)
+		}
+	}
+	return encodings
+}
+
+var inspectorTemplate = template.Must(template.New("inspector").Parse(`<!doctype html>
+<meta name="viewport" content="width=device-width">
+<title>modembed</title>
+<h1>modembed</h1>
+<p>{{len .Files}} paths, ModTime {{.ModTime.Format "2006-01-02 15:04:05"}}
+<table>
+<tr><th>Path</th><th>Size</th><th>Last Modified</th><th>ETag</th><th>Cache-Control</th><th>Cache</th><th>Variants</th><th>Requests</th><th>200</th><th>304</th><th>Other</th><th>Last</th></tr>
+{{- range .Files}}
+<tr><td>{{.Path}}</td><td>{{if .Exists}}{{.Size}}{{else}}-{{end}}</td><td>{{if .Exists}}{{.ModTime.Format "2006-01-02 15:04:05"}}{{end}}</td><td>{{.ETag}}</td><td>{{.CacheControl}}</td>
+<td>{{if .Virtual}}virtual {{end}}{{if .Materialized}}materialized {{end}}{{if .Transformed}}transformed {{end}}{{if .Compressed}}compressed{{end}}</td>
+<td>{{range .Variants}}{{.}} {{end}}</td><td>{{.Requests}}</td><td>{{.OK}}</td><td>{{.NotModified}}</td><td>{{.Other}}</td><td>{{if .LastStatus}}{{.LastStatus}} {{.LastSeen.Format "15:04:05"}}{{end}}</td></tr>
+{{- end}}
+</table>
+`))
+
+func (in *Inspector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	files, err := in.Files()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		_ = enc.Encode(files)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = inspectorTemplate.Execute(w, inspectorPage{Files: files, ModTime: in.h.fsys.ModTime()})
+}
+
+type inspectorPage struct {
+	Files   []InspectedFile
+	ModTime time.Time
+}
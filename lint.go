@@ -0,0 +1,157 @@
+package modembed
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// LintFinding 是 Lint 发现的一个问题
+type LintFinding struct {
+	Rule    string `json:"rule"`           // 规则名, 例如 "required"
+	Path    string `json:"path,omitempty"` // 相关的文件, 与具体文件无关时为空
+	Message string `json:"message"`
+}
+
+func (f LintFinding) String() string {
+	if f.Path == "" {
+		return f.Rule + ": " + f.Message
+	}
+	return f.Rule + ": " + f.Path + ": " + f.Message
+}
+
+// LintRule 检查文件树并返回发现的问题, 只有无法完成检查 (例如读取失败) 时才返回错误
+// 包内提供 LintRequire, LintMaxSize, LintForbid 与 LintReferences, 也可以自行实现
+type LintRule func(fsys fs.FS) ([]LintFinding, error)
+
+// defaultForbidden 是 LintForbid 未指定模式时禁止的文件, 通常是不应进入二进制的密钥与本地配置
+var defaultForbidden = []string{".env", ".env.*", "*.pem", "*.key", "*.p12", "*.pfx", "id_rsa", "id_ed25519", ".DS_Store"}
+
+// Lint 在启动时或测试中按顺序应用 rules 检查提供的文件树 (包括转换, 虚拟文件与别名), 返回全部问题
+//
+//	findings, err := mfs.Lint(
+//		modembed.LintRequire("index.html"),
+//		modembed.LintMaxSize(5<<20),
+//		modembed.LintForbid(),
+//		modembed.LintReferences("/"),
+//	)
+//
+// 没有问题时返回空切片; 某条规则返回错误时停止并返回该错误
+func (mfs *ModTimeFS) Lint(rules ...LintRule) ([]LintFinding, error) {
+	var findings []LintFinding
+	for _, rule := range rules {
+		found, err := rule(mfs)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, found...)
+	}
+	return findings, nil
+}
+
+// LintRequire 要求 names 中的每个路径都是存在的普通文件, 规则名为 "required"
+func LintRequire(names ...string) LintRule {
+	return func(fsys fs.FS) ([]LintFinding, error) {
+		var findings []LintFinding
+		for _, name := range names {
+			name = cleanPath(name)
+			info, err := fs.Stat(fsys, name)
+			switch {
+			case err != nil:
+				findings = append(findings, LintFinding{Rule: "required", Path: name, Message: "required file is missing"})
+			case !info.Mode().IsRegular():
+				findings = append(findings, LintFinding{Rule: "required", Path: name, Message: "required path is not a regular file"})
+			}
+		}
+		return findings, nil
+	}
+}
+
+// LintMaxSize 要求匹配 patterns (为空时为全部文件) 的文件不超过 limit 字节, 规则名为 "max-size"
+func LintMaxSize(limit int64, patterns ...string) LintRule {
+	return lintWalk(func(name string, info fs.FileInfo, findings *[]LintFinding) error {
+		if info.Size() > limit && (len(patterns) == 0 || matchAny(patterns, name)) {
+			*findings = append(*findings, LintFinding{Rule: "max-size", Path: name, Message: fmt.Sprintf("%d bytes exceeds the limit of %d", info.Size(), limit)})
+		}
+		return nil
+	})
+}
+
+// LintForbid 禁止匹配 patterns 的文件, 规则名为 "forbidden"
+// patterns 为空时禁止常见的密钥与本地配置文件 (.env, .env.*, *.pem, *.key, *.p12, *.pfx, id_rsa, id_ed25519, .DS_Store)
+func LintForbid(patterns ...string) LintRule {
+	if len(patterns) == 0 {
+		patterns = defaultForbidden
+	}
+	return lintWalk(func(name string, info fs.FileInfo, findings *[]LintFinding) error {
+		if matchAny(patterns, name) {
+			*findings = append(*findings, LintFinding{Rule: "forbidden", Path: name, Message: "file must not be embedded"})
+		}
+		return nil
+	})
+}
+
+// LintReferences 要求 HTML 页面中 <link>, <script>, <img>, <source> 与样式表中 url(), @import 引用的资源都存在于树中,
+// 规则名为 "references"; 引用的解析同 ScanPreloadHints: 以 prefix 开头的引用去掉 prefix,
+// 相对引用相对于所在文件的目录解析, 外部 URL, data: URI 与 prefix 之外的绝对路径不检查
+func LintReferences(prefix string) LintRule {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return func(fsys fs.FS) ([]LintFinding, error) {
+		var findings []LintFinding
+		err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			var refs []string
+			switch strings.ToLower(path.Ext(name)) {
+			case ".html", ".htm":
+				data, err := fs.ReadFile(fsys, name)
+				if err != nil {
+					return err
+				}
+				refs = htmlRefs(data)
+			case ".css":
+				data, err := fs.ReadFile(fsys, name)
+				if err != nil {
+					return err
+				}
+				refs = cssRefs(data)
+			default:
+				return nil
+			}
+			for _, ref := range refs {
+				ref = strings.TrimSpace(ref)
+				asset, ok := hrefToName(ref, prefix, path.Dir(name))
+				if !ok {
+					continue
+				}
+				if info, err := fs.Stat(fsys, asset); err != nil || info.IsDir() {
+					findings = append(findings, LintFinding{Rule: "references", Path: name, Message: fmt.Sprintf("reference %q does not resolve to a file (%s)", ref, asset)})
+				}
+			}
+			return nil
+		})
+		return findings, err
+	}
+}
+
+// lintWalk 返回对每个普通文件调用 check 的规则
+func lintWalk(check func(name string, info fs.FileInfo, findings *[]LintFinding) error) LintRule {
+	return func(fsys fs.FS) ([]LintFinding, error) {
+		var findings []LintFinding
+		err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return check(name, info, &findings)
+		})
+		return findings, err
+	}
+}
@@ -0,0 +1,56 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"net/http"
+	"time"
+)
+
+// LogEntry 描述 Handler 处理的一个请求
+type LogEntry struct {
+	Method   string
+	Path     string // 请求的 URL 路径, 未经清理
+	Status   int
+	Bytes    int64 // 写出的消息体字节数
+	Duration time.Duration
+
+	NotModified   bool   // 是否返回了 304
+	Encoding      string // 响应的 Content-Encoding, 未压缩时为空
+	Precompressed bool   // 内容是否来自预压缩的兄弟文件 (WithPrecompressed)
+}
+
+// WithLogger 在每个请求结束后以 LogEntry 调用 logf, 可用于接入 slog, zap 等日志库
+//
+//	modembed.WithLogger(func(e modembed.LogEntry) {
+//		slog.Info("static", "method", e.Method, "path", e.Path, "status", e.Status, "bytes", e.Bytes, "duration", e.Duration)
+//	})
+func WithLogger(logf func(LogEntry)) HandlerOption {
+	return func(h *handler) {
+		h.logf = logf
+	}
+}
+
+// observe 在请求结束时向收集器与日志报告结果
+func (h *handler) observe(sw *statusWriter, r *http.Request, start time.Time) {
+	status := sw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	duration := h.fsys.since(start)
+	if h.collector != nil {
+		h.collector.ObserveRequest(cleanPath(r.URL.Path), status, sw.bytes, duration)
+	}
+	if h.logf != nil {
+		h.logf(LogEntry{
+			Method:        r.Method,
+			Path:          r.URL.Path,
+			Status:        status,
+			Bytes:         sw.bytes,
+			Duration:      duration,
+			NotModified:   status == http.StatusNotModified,
+			Encoding:      sw.Header().Get("Content-Encoding"),
+			Precompressed: sw.precompressed,
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package modembed
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruCache 是按总开销限制大小的 LRU 缓存, 可以被多个 goroutine 并发使用
+type lruCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	maxCost int64
+	cost    int64
+	ll      *list.List // 最近使用的在前
+	items   map[K]*list.Element
+
+	onRemove func(key K, cost int64, evicted bool) // 可选, 在持有锁时对每个被移除的项调用, evicted 表示因超出上限而被淘汰
+}
+
+type lruItem[K comparable, V any] struct {
+	key   K
+	value V
+	cost  int64
+}
+
+func newLRU[K comparable, V any](maxCost int64) *lruCache[K, V] {
+	return &lruCache[K, V]{maxCost: maxCost, ll: list.New(), items: make(map[K]*list.Element)}
+}
+
+// get 返回 key 对应的值并将其标记为最近使用
+func (c *lruCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*lruItem[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// contains 判断 key 是否在缓存中, 不改变使用顺序
+func (c *lruCache[K, V]) contains(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.items[key]
+	return ok
+}
+
+// add 加入或替换 key 对应的值, 必要时淘汰最久未使用的项; 开销超过上限的值不会被缓存
+func (c *lruCache[K, V]) add(key K, value V, cost int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.removeElement(e)
+	}
+	if cost > c.maxCost {
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruItem[K, V]{key: key, value: value, cost: cost})
+	c.cost += cost
+	for c.cost > c.maxCost {
+		c.evict(c.ll.Back())
+	}
+}
+
+// remove 移除 key 对应的值
+func (c *lruCache[K, V]) remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.removeElement(e)
+	}
+}
+
+// removeIf 移除 key 满足 match 的所有值
+func (c *lruCache[K, V]) removeIf(match func(K) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.items {
+		if match(key) {
+			c.removeElement(e)
+		}
+	}
+}
+
+// clear 移除所有值
+func (c *lruCache[K, V]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.onRemove != nil {
+		for key, e := range c.items {
+			c.onRemove(key, e.Value.(*lruItem[K, V]).cost, false)
+		}
+	}
+	c.ll.Init()
+	clear(c.items)
+	c.cost = 0
+}
+
+func (c *lruCache[K, V]) removeElement(e *list.Element) {
+	c.drop(e, false)
+}
+
+func (c *lruCache[K, V]) evict(e *list.Element) {
+	c.drop(e, true)
+}
+
+func (c *lruCache[K, V]) drop(e *list.Element, evicted bool) {
+	item := c.ll.Remove(e).(*lruItem[K, V])
+	delete(c.items, item.key)
+	c.cost -= item.cost
+	if c.onRemove != nil {
+		c.onRemove(item.key, item.cost, evicted)
+	}
+}
@@ -0,0 +1,105 @@
+package modembed
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// Entry 描述清单中的一个文件
+type Entry struct {
+	Path    string    `json:"path"`             // 相对于嵌入根目录的路径, 使用 "/" 分隔
+	Size    int64     `json:"size"`             // 文件大小 (字节)
+	ModTime time.Time `json:"modTime"`          // 文件的修改时间
+	SHA256  string    `json:"sha256,omitempty"` // 可选的内容 SHA-256 (十六进制)
+}
+
+// Manifest 是一组文件记录
+// 通常由 modembed-gen 在构建时生成, 用于保留源文件的真实修改时间
+type Manifest []Entry
+
+// ReadManifest 从 r 中读取 JSON 格式的清单
+func ReadManifest(r io.Reader) (Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("modembed: decode manifest: %w", err)
+	}
+	return m, nil
+}
+
+// ReadManifestFile 从 fsys 中读取 JSON 格式的清单文件
+// 清单本身也可以一同嵌入, 例如 //go:embed static modtimes.json
+func ReadManifestFile(fsys fs.FS, name string) (Manifest, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadManifest(f)
+}
+
+// WriteJSON 将清单以缩进的 JSON 格式写入 w, 格式与 ReadManifest 兼容
+func (m Manifest) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(m)
+}
+
+// ModTimes 将清单转换为路径到修改时间的映射, 可直接用于 NewModTimeFSWithMap
+func (m Manifest) ModTimes() map[string]time.Time {
+	times := make(map[string]time.Time, len(m))
+	for _, e := range m {
+		times[e.Path] = e.ModTime
+	}
+	return times
+}
+
+// Hashes 将清单转换为路径到 SHA-256 的映射, 可直接用于 Verify; 没有记录哈希的条目被忽略
+func (m Manifest) Hashes() map[string]string {
+	hashes := make(map[string]string, len(m))
+	for _, e := range m {
+		if e.SHA256 != "" {
+			hashes[e.Path] = e.SHA256
+		}
+	}
+	return hashes
+}
+
+// NewModTimeFSFromManifest 使用清单中记录的修改时间创建 ModTimeFS
+// 清单中不存在的路径按目录继承规则查找, 最终回退到 fallback
+func NewModTimeFSFromManifest(fsys fs.FS, m Manifest, fallback time.Time) *ModTimeFS {
+	return New(fsys, WithModTime(fallback), WithManifest(m))
+}
+
+// Manifest 遍历文件系统, 返回每个文件的路径, 大小, SHA-256 与 ModTime, 按路径排序
+// 大小与哈希针对实际提供的内容 (包括转换的结果), 可用于完整性检查与预加载列表
+func (mfs *ModTimeFS) Manifest() (Manifest, error) {
+	var m Manifest
+	err := fs.WalkDir(mfs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum, err := mfs.digest(name)
+		if err != nil {
+			return err
+		}
+		m = append(m, Entry{
+			Path:    name,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			SHA256:  hex.EncodeToString(sum[:]),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,18 @@
+package modembed
+
+import (
+	"path"
+	"strings"
+)
+
+// matchPattern 判断 name 是否匹配 pattern (path.Match 语法)
+// pattern 不含 "/" 时只与文件名比较, 例如 "*.js" 匹配任意目录下的 js 文件
+// 否则与完整路径比较, 开头的 "/" 会被忽略; 无效的模式视为不匹配
+func matchPattern(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+	ok, err := path.Match(pattern, name)
+	return err == nil && ok
+}
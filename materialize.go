@@ -0,0 +1,156 @@
+package modembed
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/fs"
+)
+
+// MaterializeStats 描述 Materialize 载入内存的文件
+type MaterializeStats struct {
+	Files   int   // 载入内存的文件数
+	Bytes   int64 // 载入内存的总字节数
+	Skipped int   // 因超出预算而未载入的匹配文件数
+	Shared  int   // 设置了 WithDedupe 时, 与之前载入的文件内容相同而共用副本的文件数, 不计入 Bytes
+}
+
+type materializedSet struct {
+	files map[string]*memEntry
+	stats MaterializeStats
+}
+
+type memEntry struct {
+	data []byte
+	info fs.FileInfo // 底层文件的 FileInfo, 修改时间等设定在包装时应用
+}
+
+// Materialize 在启动时将匹配 patterns 的文件 (为空时为全部文件) 预先读入内存
+// 之后对这些文件的 Open 与 ReadFile 直接使用内存中的副本, 不再访问底层文件系统
+// budget 为最多使用的字节数, 0 表示不限制; 超出预算的文件会被跳过并计入 Skipped
+// 载入的是转换后的内容, 之后底层文件的变化 (例如 OverlayFS 中的磁盘文件) 不会反映出来
+// 再次调用会替换之前载入的内容; 设置了 WithCacheBudget 时载入的文件计入该预算, 可能在之后被淘汰
+func (mfs *ModTimeFS) Materialize(budget int64, patterns ...string) (MaterializeStats, error) {
+	set := &materializedSet{files: make(map[string]*memEntry)}
+	var contents map[[sha256.Size]byte][]byte // WithDedupe 时 内容哈希 -> 已载入的副本
+	if mfs.dedupe {
+		contents = make(map[[sha256.Size]byte][]byte)
+	}
+	var mem *budgetRegion[*memEntry]
+	if mfs.budget != nil {
+		// 文件计入共享的缓存预算, 之前载入的内容先从预算中移除
+		r := newBudgetRegion[*memEntry](mfs.budget, CacheMaterialized, mfs)
+		r.clear()
+		mem = &r
+	}
+	err := fs.WalkDir(mfs.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if len(patterns) > 0 && !matchAny(patterns, name) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// 启用 WithDedupe 时重复的内容不占用预算, 需要读取之后才能判断
+		if budget > 0 && contents == nil && set.stats.Bytes+info.Size() > budget {
+			set.stats.Skipped++
+			return nil
+		}
+		var data []byte
+		if mfs.hasTransform(name) {
+			data, err = mfs.transformed(context.Background(), name, info)
+		} else {
+			data, err = fs.ReadFile(mfs.FS, name)
+		}
+		if err != nil {
+			return err
+		}
+		var sum [sha256.Size]byte
+		shared := false
+		if contents != nil {
+			sum = sha256.Sum256(data)
+			if prev, ok := contents[sum]; ok {
+				data, shared = prev, true
+			}
+		}
+		if !shared && budget > 0 && set.stats.Bytes+int64(len(data)) > budget {
+			set.stats.Skipped++
+			return nil
+		}
+		if contents != nil && !shared {
+			contents[sum] = data
+		}
+		// 共用的副本在共享预算中仍按各自的大小计算, 因为任意一个路径被淘汰时副本不会被释放
+		if mem != nil {
+			mem.add(name, &memEntry{data: data, info: info}, int64(len(data)))
+		} else {
+			set.files[name] = &memEntry{data: data, info: info}
+		}
+		set.stats.Files++
+		if shared {
+			set.stats.Shared++
+		} else {
+			set.stats.Bytes += int64(len(data))
+		}
+		return nil
+	})
+	if err != nil {
+		return MaterializeStats{}, err
+	}
+	mfs.materialized.Store(set)
+	return set.stats, nil
+}
+
+// Materialized 返回当前载入内存的文件统计, 未调用 Materialize 时为零值
+func (mfs *ModTimeFS) Materialized() MaterializeStats {
+	if set := mfs.materialized.Load(); set != nil {
+		return set.stats
+	}
+	return MaterializeStats{}
+}
+
+// memoryEntry 返回 name 在内存中的副本
+func (mfs *ModTimeFS) memoryEntry(name string) *memEntry {
+	set := mfs.materialized.Load()
+	if set == nil {
+		return nil
+	}
+	if mfs.budget != nil {
+		e, _ := newBudgetRegion[*memEntry](mfs.budget, CacheMaterialized, mfs).get(name)
+		return e
+	}
+	return set.files[name]
+}
+
+// isMaterialized 判断 name 当前是否有内存中的副本, 不影响缓存统计
+func (mfs *ModTimeFS) isMaterialized(name string) bool {
+	set := mfs.materialized.Load()
+	if set == nil {
+		return false
+	}
+	if mfs.budget != nil {
+		return newBudgetRegion[*memEntry](mfs.budget, CacheMaterialized, mfs).contains(name)
+	}
+	return set.files[name] != nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// memFile 是内存中文件的占位 fs.File, 实际的读取与定位由 modTimeFile.buf 完成
+type memFile struct {
+	info fs.FileInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Read([]byte) (int, error)   { return 0, io.EOF }
+func (f *memFile) Close() error               { return nil }
@@ -0,0 +1,60 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// memoryContent 由内容位于内存中的读取器实现, 见 modTimeFile.take
+type memoryContent interface {
+	take(limit int64) ([]byte, bool)
+}
+
+// memoryWriter 让 http.ServeContent 直接写出内存中内容的切片
+// ServeContent 通过 io.CopyN 复制消息体, 内容的 WriteTo 不会被调用, net/http 的 ReadFrom
+// 又会先读 512 字节用于嗅探, 之后以 bufio 缓冲大小分块读取; 一次写出整个切片时 bufio 跳过缓冲,
+// 大的消息体只需要一次写入, 也不经过中间的复制
+type memoryWriter struct {
+	http.ResponseWriter
+}
+
+func (mw memoryWriter) ReadFrom(src io.Reader) (int64, error) {
+	if lr, ok := src.(*io.LimitedReader); ok {
+		if mc, ok := lr.R.(memoryContent); ok {
+			if data, ok := mc.take(lr.N); ok {
+				lr.N -= int64(len(data))
+				n, err := mw.ResponseWriter.Write(data)
+				if err == nil && n < len(data) {
+					err = io.ErrShortWrite
+				}
+				return int64(n), err
+			}
+		}
+	}
+	if rf, ok := mw.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(src)
+	}
+	// 只暴露 Write, 避免 io.Copy 再次调用 ReadFrom
+	return io.Copy(struct{ io.Writer }{mw.ResponseWriter}, src)
+}
+
+func (mw memoryWriter) Unwrap() http.ResponseWriter { return mw.ResponseWriter }
+
+// serveMemory 与 http.ServeContent 相同, 但提供的是内存中的 data
+func serveMemory(w http.ResponseWriter, r *http.Request, name string, modTime time.Time, data []byte) {
+	http.ServeContent(memoryWriter{w}, r, name, modTime, newMemReader(data))
+}
+
+// take 转发到内存中的内容, 并计入读取的字节数
+func (tr *timedReader) take(limit int64) ([]byte, bool) {
+	mc, ok := tr.ReadSeeker.(memoryContent)
+	if !ok {
+		return nil, false
+	}
+	data, ok := mc.take(limit)
+	tr.n += int64(len(data))
+	return data, ok
+}
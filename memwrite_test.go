@@ -0,0 +1,73 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// sinkResponse 把消息体复制到固定大小的缓冲中后丢弃, 模拟写入连接时每个字节的开销
+type sinkResponse struct {
+	header http.Header
+	sink   []byte
+}
+
+func (s *sinkResponse) Header() http.Header { return s.header }
+func (s *sinkResponse) WriteHeader(int)     {}
+func (s *sinkResponse) Write(p []byte) (int, error) {
+	for rest := p; len(rest) > 0; {
+		rest = rest[copy(s.sink, rest):]
+	}
+	return len(p), nil
+}
+
+func TestMemoryWriterBody(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	for _, header := range []http.Header{nil, {"Range": {"bytes=100-40000"}}} {
+		r := httptest.NewRequest(http.MethodGet, "/data.bin", nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		serveMemory(w, r, "data.bin", testModTime, data)
+		want := data
+		if header != nil {
+			want = data[100:40001]
+		}
+		if !bytes.Equal(w.Body.Bytes(), want) {
+			t.Errorf("Range %q: body is %d bytes, want %d", header.Get("Range"), w.Body.Len(), len(want))
+		}
+	}
+}
+
+// BenchmarkServeMemory 比较 memoryWriter 一次写出切片与 io.CopyN 逐块 Read 的吞吐量
+func BenchmarkServeMemory(b *testing.B) {
+	for _, size := range []int{4 << 10, 1 << 20, 16 << 20} {
+		data := bytes.Repeat([]byte{'x'}, size)
+		r := httptest.NewRequest(http.MethodGet, "/data.bin", nil)
+		paths := []struct {
+			name string
+			wrap func(http.ResponseWriter) http.ResponseWriter
+		}{
+			{"memoryWriter", func(w http.ResponseWriter) http.ResponseWriter { return memoryWriter{w} }},
+			{"ReadLoop", func(w http.ResponseWriter) http.ResponseWriter { return w }},
+		}
+		for _, p := range paths {
+			b.Run(fmt.Sprintf("%s/%dKiB", p.name, size>>10), func(b *testing.B) {
+				b.SetBytes(int64(size))
+				b.ReportAllocs()
+				w := &sinkResponse{header: make(http.Header), sink: make([]byte, 64<<10)}
+				for b.Loop() {
+					clear(w.header)
+					content := io.ReadSeeker(newMemReader(data))
+					http.ServeContent(p.wrap(w), r, "data.bin", testModTime, content)
+				}
+			})
+		}
+	}
+}
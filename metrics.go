@@ -0,0 +1,144 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// Collector 接收 Handler 的运行指标, 实现需要可以被多个 goroutine 并发调用
+// 304 命中率可以由 ObserveRequest 的状态码得出; 现成的 Prometheus 实现见 modembedprom 子包
+type Collector interface {
+	// ObserveRequest 在每个请求结束时调用
+	// name 为清理后的请求路径 (与文件路径的形式相同), bytes 为写出的消息体字节数
+	ObserveRequest(name string, status int, bytes int64, duration time.Duration)
+	// ObserveOpen 在每次打开文件之后调用, err 为打开的结果
+	ObserveOpen(name string, duration time.Duration, err error)
+	// ObserveRead 在提供文件内容之后调用, 报告从文件中读取的字节数与耗时
+	ObserveRead(name string, bytes int64, duration time.Duration)
+}
+
+// WithCollector 为 Handler 设置指标收集器
+func WithCollector(c Collector) HandlerOption {
+	return func(h *handler) {
+		h.collector = c
+	}
+}
+
+// WithTracer 为每个请求创建追踪区间 (SpanServe), 并让请求中的打开, 转换与压缩创建子区间
+// 基于 OpenTelemetry 的实现见 modembedotel 子包
+func WithTracer(t Tracer) HandlerOption {
+	return func(h *handler) {
+		h.tracer = t
+	}
+}
+
+// traceRequest 为 r 开始 SpanServe 区间, 返回携带该区间与 Tracer 的请求
+func (h *handler) traceRequest(r *http.Request) (*http.Request, TraceSpan) {
+	ctx, span := h.tracer.Start(ContextWithTracer(r.Context(), h.tracer), SpanServe)
+	span.SetAttr(AttrPath, cleanPath(r.URL.Path))
+	return r.WithContext(ctx), span
+}
+
+// endTrace 以响应的结果结束请求的区间, 5xx 响应记录为错误
+func (h *handler) endTrace(span TraceSpan, sw *statusWriter, r *http.Request) {
+	status := sw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	span.SetAttr(AttrStatusCode, status)
+	span.SetAttr(AttrBodySize, sw.bytes)
+	if enc := sw.Header().Get("Content-Encoding"); enc != "" {
+		span.SetAttr(AttrEncoding, enc)
+	}
+	var err error
+	if status >= 500 {
+		err = fmt.Errorf("modembed: %s", http.StatusText(status))
+	} else if cerr := r.Context().Err(); cerr != nil {
+		err = cerr
+	}
+	span.End(err)
+}
+
+// open 以请求的 context 打开 name 并向收集器报告耗时
+func (h *handler) open(r *http.Request, name string) (fs.File, error) {
+	if h.collector == nil {
+		return h.fsys.OpenContext(r.Context(), name)
+	}
+	start := h.fsys.now()
+	f, err := h.fsys.OpenContext(r.Context(), name)
+	h.collector.ObserveOpen(name, h.fsys.since(start), err)
+	return f, err
+}
+
+// reader 返回提供 f 内容时使用的 io.ReadSeeker, 设置了收集器时统计读取的字节数与耗时
+// 返回的函数在内容提供完毕后调用
+func (h *handler) reader(name string, f fs.File) (io.ReadSeeker, func()) {
+	rs := f.(io.ReadSeeker)
+	if h.collector == nil {
+		return rs, func() {}
+	}
+	tr := &timedReader{ReadSeeker: rs, fsys: h.fsys}
+	return tr, func() { h.collector.ObserveRead(name, tr.n, tr.d) }
+}
+
+// timedReader 累计 Read 读取的字节数与耗时
+type timedReader struct {
+	io.ReadSeeker
+	fsys *ModTimeFS // 提供时钟
+	n    int64
+	d    time.Duration
+}
+
+func (tr *timedReader) Read(p []byte) (int, error) {
+	start := tr.fsys.now()
+	n, err := tr.ReadSeeker.Read(p)
+	tr.d += tr.fsys.since(start)
+	tr.n += int64(n)
+	return n, err
+}
+
+// statusWriter 记录写出的状态码与消息体字节数
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+
+	precompressed bool // 由 serveContent 在提供预压缩变体时设置
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if sw.status == 0 && code >= 200 { // 1xx 信息响应之后还有最终响应
+		sw.status = code
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	n, err := sw.ResponseWriter.Write(p)
+	sw.bytes += int64(n)
+	return n, err
+}
+
+func (sw *statusWriter) Unwrap() http.ResponseWriter { return sw.ResponseWriter }
+
+// findStatusWriter 返回 w 或它包装的 ResponseWriter 中的 statusWriter, 没有时返回 nil
+func findStatusWriter(w http.ResponseWriter) *statusWriter {
+	for {
+		switch ww := w.(type) {
+		case *statusWriter:
+			return ww
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = ww.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
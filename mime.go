@@ -0,0 +1,62 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// WithContentTypes 设置按扩展名覆盖的 Content-Type, 例如 {".wasm": "application/wasm"}
+// 扩展名不区分大小写, 可以省略开头的 "."
+// 未覆盖的扩展名仍使用 mime.TypeByExtension, 其结果依赖系统的 mime 数据库, 在不同机器和容器中可能不同
+func WithContentTypes(types map[string]string) HandlerOption {
+	normalized := make(map[string]string, len(types))
+	for ext, ctype := range types {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized[ext] = ctype
+	}
+	return func(h *handler) {
+		// 每个 Handler 使用自己的副本, 同一个选项用于多个 Handler 时互不影响
+		if h.contentTypes == nil {
+			h.contentTypes = make(map[string]string, len(normalized))
+		}
+		for ext, ctype := range normalized {
+			h.contentTypes[ext] = ctype
+		}
+	}
+}
+
+// overrideContentType 返回用户为 name 的扩展名设置的 Content-Type
+func (h *handler) overrideContentType(name string) (string, bool) {
+	if h.contentTypes == nil {
+		return "", false
+	}
+	ctype, ok := h.contentTypes[strings.ToLower(path.Ext(name))]
+	return ctype, ok
+}
+
+// contentType 返回 name 的 MIME 类型
+// 扩展名未知时读取原始内容的前 512 字节进行嗅探, 避免对压缩后的内容进行嗅探
+func (h *handler) contentType(name string) string {
+	if ctype, ok := h.overrideContentType(name); ok {
+		return ctype
+	}
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		return ctype
+	}
+	f, err := h.fsys.Open(name)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+	var buf [512]byte
+	n, _ := io.ReadFull(f, buf[:])
+	return http.DetectContentType(buf[:n])
+}
@@ -0,0 +1,22 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithContentTypesNotShared(t *testing.T) {
+	mfs := New(fstest.MapFS{"a.foo": {Data: []byte("x")}})
+	shared := WithContentTypes(map[string]string{"foo": "text/x-foo"})
+	h1 := Handler(mfs, shared).(*handler)
+	h2 := Handler(mfs, shared, WithContentTypes(map[string]string{".foo": "text/x-other"})).(*handler)
+
+	if got, _ := h1.overrideContentType("a.foo"); got != "text/x-foo" {
+		t.Errorf("first handler Content-Type = %q, want text/x-foo", got)
+	}
+	if got, _ := h2.overrideContentType("a.foo"); got != "text/x-other" {
+		t.Errorf("second handler Content-Type = %q, want text/x-other", got)
+	}
+}
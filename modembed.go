@@ -1,54 +1,267 @@
 package modembed
 
 import (
+	"bytes"
+	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// ModTimeFS 是一个包装了 embed.FS 的文件系统
+// ModTimeFS 是一个包装了 fs.FS (通常是 embed.FS) 的文件系统
 // 它为所有文件使用用户提供的固定 ModTime
+//
+// 无论底层实现了哪些可选接口, ModTimeFS 总是实现 fs.StatFS, fs.ReadFileFS, fs.ReadDirFS, fs.GlobFS 与 fs.ReadLinkFS;
+// 底层实现了对应接口时转发给它, 否则与 fs.Stat 等函数一样回退到 Open, 因此结果与直接使用底层一致
+// 底层不支持符号链接时 ReadLink 返回 fs.ErrInvalid, Lstat 等同于 Stat
+// Sub 返回 *ModTimeFS, 因此不满足 fs.SubFS, 即使底层实现了它; fs.Sub 仍然可用, 但得到的不再是 *ModTimeFS
 type ModTimeFS struct {
-	embed.FS
-	modTime time.Time // 用户设定的统一修改时间
+	fs.FS
+	source   fs.FS                     // 应用解压, 别名与隐藏之前的底层文件系统, 为 nil 时与 FS 相同
+	modTime  atomic.Pointer[time.Time] // 用户设定的统一修改时间, 作为 modTimes 未命中时的回退值, 可由 SetModTime 更新
+	normTime func(time.Time) time.Time // 规范化 (UTC, 截断到秒) 之后加入的时间, 例如虚拟文件的时间
+	modTimes map[string]time.Time      // 可选的逐路径修改时间, 键为清理后的路径
+	rules    []ModTimeRule             // 可选的按模式匹配的修改时间, 在 modTimes 之后检查
+
+	modTimeFunc func(name string) time.Time // 可选的动态修改时间, 在 modTimes 之前检查
+
+	transforms []transformRule // 可选的内容转换
+
+	digests         sync.Map // 内容哈希缓存 路径 -> *digestEntry
+	transformCache  sync.Map // 转换结果缓存 路径 -> *transformEntry, 设置了 budget 时不使用
+	transformFlight flightGroup[string, []byte]
+	contentTime     *contentTimeRule // 可选的从内容中读取修改时间的规则
+	contentTimes    sync.Map         // 路径 -> *contentTime
+	integrity       sync.Map         // SRI 缓存 路径 -> *sriEntry
+	dataURIs        sync.Map         // data: URI 缓存 路径 -> *dataURIEntry
+	infos           sync.Map         // 包装后的 FileInfo 缓存 路径 -> *infoEntry
+
+	materialized atomic.Pointer[materializedSet] // Materialize 载入内存的文件
+
+	virtualMu sync.Mutex                 // 串行化 AddVirtual / RemoveVirtual
+	virtual   atomic.Pointer[virtualSet] // 通过 AddVirtual 注册的文件
+
+	dirCache map[string][]fs.DirEntry // WithDirCache 构建的目录条目缓存, 创建后只读
+	misses   *negativeCache           // WithNegativeCache 记录的缺失路径, 为 nil 表示不启用
+	clock    Clock                    // WithClock 设置的时钟, 为 nil 时使用 time.Now
+
+	weakETags *weakETagRule  // WithWeakETags 的设定, 为 nil 表示总是使用强 ETag
+	budget    *CacheBudget   // WithCacheBudget 设置的共享缓存预算, 为 nil 时各缓存独立
+	dedupe    bool           // WithDedupe, 内容相同的文件共享缓存
+	fileModes []fileModeRule // WithFileMode 的规则
 }
 
 // NewModTimeFS 创建一个新的 ModTimeFS 实例
 // efs 是底层的 embed.FS
 // fixedModTime 是用户希望应用到所有嵌入文件的修改时间
-// 如果 fixedModTime 为零值 time.Time{} 则 ModTime 将保持 embed.FS 的默认行为 (即零时)
+// 如果 fixedModTime 为零值 time.Time{} 则 ModTime 将保持底层文件系统的行为 (embed.FS 即为零时)
 // 这可能导致 http.FileServer 无法正确处理304
 // 建议用户总是提供一个有意义的非零时间
 func NewModTimeFS(efs embed.FS, fixedModTime time.Time) *ModTimeFS {
-	// 可以选择在这里加一个警告 如果 fixedModTime 是零值
-	// if fixedModTime.IsZero() {
-	//	 fmt.Fprintln(os.Stderr, "Warning: modembed.NewModTimeFS called with zero time. HTTP 304 caching might not work as expected.")
-	// }
-	return &ModTimeFS{
-		FS:      efs,
-		modTime: fixedModTime.UTC(), // 确保使用UTC以保持一致性
+	// 需要零值警告或回退时可以使用 New(efs, WithModTime(t), WithZeroTimePolicy(...))
+	return New(efs, WithModTime(fixedModTime))
+}
+
+// NewModTimeFSFromFS 与 NewModTimeFS 相同 但接受任意 fs.FS
+// 例如 fstest.MapFS, zip.Reader 或 os.DirFS
+func NewModTimeFSFromFS(fsys fs.FS, fixedModTime time.Time) *ModTimeFS {
+	return New(fsys, WithModTime(fixedModTime))
+}
+
+// NewModTimeFSWithMap 创建一个按路径设定 ModTime 的 ModTimeFS
+// times 的键会经过 path.Clean 处理, 查找时若路径本身没有记录则逐级向上继承父目录的时间
+// 所有层级都没有记录时使用 fallback
+func NewModTimeFSWithMap(efs embed.FS, times map[string]time.Time, fallback time.Time) *ModTimeFS {
+	return New(efs, WithModTime(fallback), WithModTimeMap(times))
+}
+
+// cleanModTimes 规范化逐路径时间表的键, 并对时间应用 normalize
+func cleanModTimes(times map[string]time.Time, normalize func(time.Time) time.Time) map[string]time.Time {
+	if len(times) == 0 {
+		return nil
+	}
+	cleaned := make(map[string]time.Time, len(times))
+	for name, t := range times {
+		cleaned[cleanPath(name)] = normalize(t)
+	}
+	return cleaned
+}
+
+// cleanPath 将路径规范化为 fs.FS 使用的无根形式, 根目录为 "."
+func cleanPath(name string) string {
+	if fs.ValidPath(name) {
+		return name // 合法的 fs.FS 路径已经是清理过的形式, 避免分配
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// modTimeOf 返回 name 对应的修改时间
+// 依次检查虚拟文件自带的时间, WithModTimeFunc, 文件内容中的时间, 逐路径映射 (含目录继承), 模式规则, 最后使用统一的修改时间
+func (mfs *ModTimeFS) modTimeOf(name string) time.Time {
+	name = cleanPath(name)
+	vf := mfs.virtualFileOf(name)
+	if vf != nil && !vf.modTime.IsZero() {
+		return vf.modTime
+	}
+	if mfs.modTimeFunc != nil {
+		if t := mfs.modTimeFunc(name); !t.IsZero() {
+			return mfs.normTime(t)
+		}
+	}
+	if t := mfs.contentModTime(name, vf); !t.IsZero() {
+		return t
+	}
+	if mfs.modTimes != nil {
+		for n := name; ; n = path.Dir(n) {
+			if t, ok := mfs.modTimes[n]; ok {
+				return t
+			}
+			if n == "." {
+				break
+			}
+		}
+	}
+	for _, rule := range mfs.rules {
+		if matchPattern(rule.Glob, name) {
+			return rule.Time
+		}
+	}
+	return mfs.ModTime()
+}
+
+// ModTime 返回当前的统一修改时间
+func (mfs *ModTimeFS) ModTime() time.Time {
+	if t := mfs.modTime.Load(); t != nil {
+		return *t
 	}
+	return time.Time{}
+}
+
+// SetModTime 在运行时更新统一修改时间, 可以与正在进行的请求并发调用
+// 例如热重载影响渲染结果的配置之后更新 Last-Modified, 而无需重新创建文件系统或 Handler
+// 时间按创建时的 WithUTC 与 WithTruncateSeconds 设定规范化; 逐路径映射与模式规则的优先级不变
+func (mfs *ModTimeFS) SetModTime(t time.Time) {
+	if mfs.normTime != nil {
+		t = mfs.normTime(t)
+	}
+	mfs.modTime.Store(&t)
+}
+
+// wrapInfo 为 name 的 FileInfo 应用修改时间设定, 有内容转换时同时修正 Size
+func (mfs *ModTimeFS) wrapInfo(name string, info fs.FileInfo) (fs.FileInfo, error) {
+	modTime := mfs.modTimeOf(name)
+	// 底层文件系统每次返回同一个 FileInfo 时 (例如 embed.FS) 复用之前的包装, 使 Stat 不产生额外分配
+	cacheable := reflect.TypeOf(info).Kind() == reflect.Pointer
+	if cacheable {
+		if v, ok := mfs.infos.Load(name); ok {
+			if e := v.(*infoEntry); e.base == info && e.modTime.Equal(modTime) {
+				return e.wrapped, nil
+			}
+		}
+	}
+
+	wrapped := &modTimeFileInfo{FileInfo: info, modTime: modTime, size: -1, mode: mfs.fileMode(name, info.Mode())}
+	if base := path.Base(name); name != "." && base != info.Name() {
+		wrapped.name = base // 通过别名打开时底层报告的是目标的名称
+	}
+	if info.Mode().IsRegular() && mfs.hasTransform(name) && mfs.virtualFileOf(name) == nil {
+		data, err := mfs.transformed(context.Background(), name, info)
+		if err != nil {
+			return nil, err
+		}
+		wrapped.size = int64(len(data))
+	}
+	if cacheable {
+		mfs.infos.Store(name, &infoEntry{base: info, modTime: modTime, wrapped: wrapped})
+	}
+	return wrapped, nil
+}
+
+// cachedInfo 在底层为 embed.FS 时直接返回之前包装的 FileInfo
+// embed.FS 没有 Stat 方法, fs.Stat 每次都要打开文件并分配; 它的内容不会变化, 只需确认修改时间设定没有改变
+func (mfs *ModTimeFS) cachedInfo(name string) (fs.FileInfo, bool) {
+	if _, ok := mfs.FS.(embed.FS); !ok || mfs.virtualFileOf(name) != nil {
+		return nil, false
+	}
+	v, ok := mfs.infos.Load(name)
+	if !ok {
+		return nil, false
+	}
+	e := v.(*infoEntry)
+	if _, virtual := e.base.(*virtualInfo); virtual || !e.modTime.Equal(mfs.modTimeOf(name)) {
+		return nil, false // 已移除的虚拟文件或虚拟目录
+	}
+	return e.wrapped, true
+}
+
+// infoEntry 缓存一个路径包装后的 FileInfo, 底层 FileInfo 与修改时间都不变时有效
+type infoEntry struct {
+	base    fs.FileInfo
+	modTime time.Time
+	wrapped fs.FileInfo
 }
 
 // --- fs.FileInfo 包装  ---
 type modTimeFileInfo struct {
 	fs.FileInfo
 	modTime time.Time
+	size    int64  // 内容经过转换时的大小, 为 -1 表示沿用底层大小
+	name    string // 与底层名称不同时的名称, 为空表示沿用底层名称
+	mode    fs.FileMode
 }
 
-func (mfi *modTimeFileInfo) ModTime() time.Time { return mfi.modTime }
-func (mfi *modTimeFileInfo) Name() string       { return mfi.FileInfo.Name() }
-func (mfi *modTimeFileInfo) Size() int64        { return mfi.FileInfo.Size() }
-func (mfi *modTimeFileInfo) Mode() fs.FileMode  { return mfi.FileInfo.Mode() }
-func (mfi *modTimeFileInfo) IsDir() bool        { return mfi.FileInfo.IsDir() }
-func (mfi *modTimeFileInfo) Sys() interface{}   { return mfi.FileInfo.Sys() }
+// ModTime 返回设定的修改时间, 未设定 (零值) 时沿用底层文件系统报告的时间
+func (mfi *modTimeFileInfo) ModTime() time.Time {
+	if mfi.modTime.IsZero() {
+		return mfi.FileInfo.ModTime()
+	}
+	return mfi.modTime
+}
+func (mfi *modTimeFileInfo) Name() string {
+	if mfi.name != "" {
+		return mfi.name
+	}
+	return mfi.FileInfo.Name()
+}
+func (mfi *modTimeFileInfo) Size() int64 {
+	if mfi.size >= 0 {
+		return mfi.size
+	}
+	return mfi.FileInfo.Size()
+}
+func (mfi *modTimeFileInfo) Mode() fs.FileMode { return mfi.mode }
+func (mfi *modTimeFileInfo) IsDir() bool       { return mfi.FileInfo.IsDir() }
+func (mfi *modTimeFileInfo) Sys() interface{}  { return mfi.FileInfo.Sys() }
 
 // --- fs.DirEntry 包装  ---
 type modTimeDirEntry struct {
 	fs.DirEntry
-	modTime time.Time
+	dir string // 条目所在的目录, 完整路径在 Info 时才拼接
+	mfs *ModTimeFS
+}
+
+func wrapDirEntries(mfs *ModTimeFS, dir string, entries []fs.DirEntry) []fs.DirEntry {
+	// 所有包装放在同一块内存中, 每次调用只分配两次
+	wrapped := make([]modTimeDirEntry, len(entries))
+	wrappedEntries := make([]fs.DirEntry, len(entries))
+	for i, entry := range entries {
+		wrapped[i] = modTimeDirEntry{DirEntry: entry, dir: dir, mfs: mfs}
+		wrappedEntries[i] = &wrapped[i]
+	}
+	return wrappedEntries
 }
 
 func (mde *modTimeDirEntry) Info() (fs.FileInfo, error) {
@@ -56,7 +269,7 @@ func (mde *modTimeDirEntry) Info() (fs.FileInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &modTimeFileInfo{FileInfo: info, modTime: mde.modTime}, nil
+	return mde.mfs.wrapInfo(joinPath(mde.dir, mde.DirEntry.Name()), info)
 }
 func (mde *modTimeDirEntry) Name() string      { return mde.DirEntry.Name() }
 func (mde *modTimeDirEntry) IsDir() bool       { return mde.DirEntry.IsDir() }
@@ -65,7 +278,19 @@ func (mde *modTimeDirEntry) Type() fs.FileMode { return mde.DirEntry.Type() }
 // --- fs.File 包装  ---
 type modTimeFile struct {
 	fs.File
-	modTime time.Time
+	name string // 打开时使用的路径, 用于计算目录项的修改时间
+	mfs  *ModTimeFS
+
+	// 底层文件不支持 Seek 时, 首次 Seek 会通过 ReadFile 读入全部内容
+	// 之后的读取与定位都在 buf 上进行; 内容经过转换的文件在打开时即使用 buf
+	pos int64
+	buf *memReader
+
+	// ReadDir 在首次调用时读入的全部条目 (目录下存在虚拟文件时为合并后的条目)
+	dirEntries []fs.DirEntry
+	dirErr     error // 读取底层目录时遇到的错误, 在条目返回完之后报告
+	dirLoaded  bool
+	dirOffset  int
 }
 
 func (mf *modTimeFile) Stat() (fs.FileInfo, error) {
@@ -73,57 +298,322 @@ func (mf *modTimeFile) Stat() (fs.FileInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &modTimeFileInfo{FileInfo: info, modTime: mf.modTime}, nil
+	return mf.mfs.wrapInfo(mf.name, info)
+}
+func (mf *modTimeFile) Read(p []byte) (int, error) {
+	if mf.buf != nil {
+		return mf.buf.Read(p)
+	}
+	n, err := mf.File.Read(p)
+	mf.pos += int64(n)
+	return n, err
 }
-func (mf *modTimeFile) Read(p []byte) (int, error) { return mf.File.Read(p) }
-func (mf *modTimeFile) Close() error               { return mf.File.Close() }
+func (mf *modTimeFile) Close() error { return mf.File.Close() }
 func (mf *modTimeFile) Seek(offset int64, whence int) (int64, error) {
-	if seeker, ok := mf.File.(io.Seeker); ok {
-		return seeker.Seek(offset, whence)
+	if mf.buf == nil {
+		if seeker, ok := mf.File.(io.Seeker); ok {
+			return seeker.Seek(offset, whence)
+		}
+		if err := mf.loadBuffer(); err != nil {
+			return 0, &fs.PathError{Op: "seek", Path: mf.name, Err: fmt.Errorf("%w: %w", ErrNotSeekable, err)}
+		}
+	}
+	return mf.buf.Seek(offset, whence)
+}
+
+// ReadAt 在底层文件实现 io.ReaderAt 时直接转发, 否则使用 ReadFile 读入的内存缓冲
+// ReadAt 不影响 Read 与 Seek 使用的当前位置
+func (mf *modTimeFile) ReadAt(p []byte, off int64) (int, error) {
+	if mf.buf == nil {
+		if ra, ok := mf.File.(io.ReaderAt); ok {
+			return ra.ReadAt(p, off)
+		}
+		if err := mf.loadBuffer(); err != nil {
+			return 0, &fs.PathError{Op: "readat", Path: mf.name, Err: fmt.Errorf("%w: %w", ErrNotSeekable, err)}
+		}
+	}
+	return mf.buf.ReadAt(p, off)
+}
+
+// WriteTo 将剩余内容写入 w, 内存中的内容与实现了 io.WriterTo 的底层文件直接写出
+// 使 io.Copy 不必经过中间缓冲
+func (mf *modTimeFile) WriteTo(w io.Writer) (int64, error) {
+	if mf.buf != nil {
+		return mf.buf.WriteTo(w)
+	}
+	if wt, ok := mf.File.(io.WriterTo); ok {
+		n, err := wt.WriteTo(w)
+		mf.pos += n
+		return n, err
+	}
+	// 只暴露 Read, 避免 io.Copy 再次调用 WriteTo
+	return io.Copy(w, readerFunc(mf.Read))
+}
+
+// readerFunc 将函数适配为 io.Reader
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+// take 在内容位于内存中时返回当前位置之后最多 limit 字节的内部切片, 并将位置移动到其后
+// 调用方只能读取返回的切片; 内容不在内存中时返回 false
+func (mf *modTimeFile) take(limit int64) ([]byte, bool) {
+	if mf.buf == nil {
+		return nil, false
+	}
+	return mf.buf.take(limit)
+}
+
+// memReader 是同时保留底层切片的 bytes.Reader, 使写出时可以直接使用切片而不必复制
+type memReader struct {
+	bytes.Reader
+	data []byte
+}
+
+func newMemReader(data []byte) *memReader {
+	m := &memReader{data: data}
+	m.Reset(data)
+	return m
+}
+
+func (m *memReader) take(limit int64) ([]byte, bool) {
+	off := m.Size() - int64(m.Len())
+	n := min(int64(m.Len()), max(limit, 0))
+	m.Seek(n, io.SeekCurrent)
+	return m.data[off : off+n], true
+}
+
+// loadBuffer 通过 ReadFile 读入完整内容, 并将缓冲的位置对齐到已经 Read 的字节数
+func (mf *modTimeFile) loadBuffer() error {
+	data, err := fs.ReadFile(mf.mfs.FS, mf.name)
+	if err != nil {
+		return err
+	}
+	buf := newMemReader(data)
+	if _, err := buf.Seek(mf.pos, io.SeekStart); err != nil {
+		return err
 	}
-	return 0, fmt.Errorf("file does not support Seek")
+	mf.buf = buf
+	return nil
 }
+
+// ReadDir 按 fs.ReadDirFile 的语义返回目录条目, 条目总是按名称排序 (与 embed.FS 和 fs.ReadDir 一致)
+// count > 0 时每次最多返回 count 个条目, 没有剩余条目时返回 io.EOF; count <= 0 时返回剩余的全部条目与 nil
+// 首次调用时读入全部条目, 读取底层目录出错时先返回已经读到的条目, 之后返回该错误
 func (mf *modTimeFile) ReadDir(count int) ([]fs.DirEntry, error) {
-	if rdf, ok := mf.File.(fs.ReadDirFile); ok {
-		entries, err := rdf.ReadDir(count)
-		if err != nil {
+	if !mf.dirLoaded {
+		if err := mf.loadDir(); err != nil {
 			return nil, err
 		}
-		wrappedEntries := make([]fs.DirEntry, len(entries))
-		for i, entry := range entries {
-			wrappedEntries[i] = &modTimeDirEntry{DirEntry: entry, modTime: mf.modTime}
+	}
+	entries, err := readDirPage(mf.dirEntries, &mf.dirOffset, count)
+	if mf.dirErr != nil && mf.dirOffset == len(mf.dirEntries) && (count <= 0 || err == io.EOF) {
+		// 条目已经全部返回, 报告读取时遇到的错误
+		return entries, mf.dirErr
+	}
+	return entries, err
+}
+
+// loadDir 读入目录的全部条目并排序
+func (mf *modTimeFile) loadDir() error {
+	if entries, ok := mf.mfs.cachedDir(mf.name); ok {
+		mf.dirEntries, mf.dirLoaded = entries, true
+		return nil
+	}
+	if mf.mfs.virtualChildren(mf.name) != nil {
+		entries, err := mf.mfs.ReadDir(mf.name)
+		if err != nil {
+			return err
 		}
-		return wrappedEntries, nil
+		mf.dirEntries, mf.dirLoaded = entries, true
+		return nil
+	}
+	rdf, ok := mf.File.(fs.ReadDirFile)
+	if !ok {
+		return &fs.PathError{Op: "readdir", Path: mf.name, Err: ErrNotDirectory}
+	}
+	entries, err := rdf.ReadDir(-1)
+	if err != nil && len(entries) == 0 {
+		return err
 	}
-	return nil, fmt.Errorf("file is not a directory or does not support ReadDir")
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	mf.dirEntries, mf.dirErr, mf.dirLoaded = wrapDirEntries(mf.mfs, mf.name, entries), err, true
+	return nil
 }
 
 // --- ModTimeFS 方法实现  ---
 func (mfs *ModTimeFS) Open(name string) (fs.File, error) {
+	return mfs.OpenContext(context.Background(), name)
+}
+
+// OpenContext 与 Open 相同, 但在 ctx 结束时放弃等待第一次的内容转换并返回 ctx.Err()
+// 转换由多个并发的调用共享, 在所有等待它的调用都结束后才会停止; Handler 使用请求的 context 调用它
+func (mfs *ModTimeFS) OpenContext(ctx context.Context, name string) (fs.File, error) {
+	ctx, span := startSpan(ctx, SpanOpen)
+	if span == nil {
+		return mfs.openContext(ctx, name)
+	}
+	span.SetAttr(AttrPath, name)
+	switch {
+	case mfs.virtualFileOf(name) != nil:
+		span.SetAttr(AttrSource, "virtual")
+	case mfs.isMaterialized(name):
+		span.SetAttr(AttrSource, "memory")
+	default:
+		span.SetAttr(AttrSource, "fs")
+	}
+	f, err := mfs.openContext(ctx, name)
+	span.End(err)
+	return f, err
+}
+
+func (mfs *ModTimeFS) openContext(ctx context.Context, name string) (fs.File, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if vf := mfs.virtualFileOf(name); vf != nil {
+		return &modTimeFile{File: &memFile{info: vf.fi}, name: name, mfs: mfs, buf: newMemReader(vf.data)}, nil
+	}
+	if e := mfs.memoryEntry(name); e != nil {
+		return &modTimeFile{File: &memFile{info: e.info}, name: name, mfs: mfs, buf: newMemReader(e.data)}, nil
+	}
+	if err := mfs.cachedMiss("open", name); err != nil {
+		return nil, err
+	}
 	file, err := mfs.FS.Open(name)
 	if err != nil {
+		if mfs.virtualChildren(name) != nil {
+			// 只由虚拟文件隐含的目录
+			return &modTimeFile{File: &memFile{info: virtualDirInfo(name)}, name: name, mfs: mfs}, nil
+		}
+		mfs.recordMiss("open", name, err)
 		return nil, err
 	}
-	return &modTimeFile{File: file, modTime: mfs.modTime}, nil
+	mf := &modTimeFile{File: file, name: name, mfs: mfs}
+	if mfs.hasTransform(name) {
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if !info.IsDir() {
+			data, err := mfs.transformed(ctx, name, info)
+			if err != nil {
+				file.Close()
+				return nil, err
+			}
+			mf.buf = newMemReader(data)
+		}
+	}
+	return mf, nil
 }
 
 func (mfs *ModTimeFS) ReadFile(name string) ([]byte, error) {
-	return mfs.FS.ReadFile(name) // ModTime不影响内容读取
+	data, shared, err := mfs.contents(name)
+	if shared {
+		data = bytes.Clone(data)
+	}
+	return data, err
+}
+
+// BytesUnsafe 返回 name 的内容, 与 ReadFile 不同, 在可能时不复制
+// 虚拟文件, Materialize 载入内存的文件与转换结果直接返回内部的切片, 其余文件与 ReadFile 相同
+// 返回的切片是只读的, 调用方不得修改, 也不应长期持有 (文件更新后内部缓存会被替换)
+func (mfs *ModTimeFS) BytesUnsafe(name string) ([]byte, error) {
+	data, _, err := mfs.contents(name)
+	return data, err
+}
+
+// contents 返回 name 的内容以及它是否与内部状态共享
+func (mfs *ModTimeFS) contents(name string) ([]byte, bool, error) {
+	if vf := mfs.virtualFileOf(name); vf != nil {
+		return vf.data, true, nil
+	}
+	if e := mfs.memoryEntry(name); e != nil {
+		return e.data, true, nil
+	}
+	if mfs.hasTransform(name) {
+		info, err := fs.Stat(mfs.FS, name)
+		if err != nil {
+			return nil, false, err
+		}
+		if !info.IsDir() {
+			data, err := mfs.transformed(context.Background(), name, info)
+			if err != nil {
+				return nil, false, err
+			}
+			return data, true, nil
+		}
+	}
+	data, err := fs.ReadFile(mfs.FS, name) // ModTime不影响内容读取
+	return data, false, err
 }
 
 func (mfs *ModTimeFS) ReadDir(name string) ([]fs.DirEntry, error) {
-	entries, err := mfs.FS.ReadDir(name)
+	if entries, ok := mfs.cachedDir(name); ok {
+		return entries, nil
+	}
+	entries, err := fs.ReadDir(mfs.FS, name)
+	if children := mfs.virtualChildren(name); children != nil {
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		// mergeVirtual 会修改 children, 传入副本
+		cp := make(map[string]bool, len(children))
+		for child := range children {
+			cp[child] = true
+		}
+		entries, err = mfs.mergeVirtual(name, entries, cp), nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	wrappedEntries := make([]fs.DirEntry, len(entries))
-	for i, entry := range entries {
-		wrappedEntries[i] = &modTimeDirEntry{DirEntry: entry, modTime: mfs.modTime}
+	return wrapDirEntries(mfs, name, entries), nil
+}
+
+func (mfs *ModTimeFS) Stat(name string) (fs.FileInfo, error) {
+	if info, ok := mfs.cachedInfo(name); ok {
+		return info, nil
 	}
-	return wrappedEntries, nil
+	info, err := mfs.rawStat(name)
+	if err != nil {
+		return nil, err
+	}
+	return mfs.wrapInfo(name, info)
+}
+
+func (mfs *ModTimeFS) Glob(pattern string) ([]string, error) {
+	if mfs.virtual.Load() != nil {
+		return fs.Glob(globFS{mfs: mfs}, pattern)
+	}
+	return fs.Glob(mfs.FS, pattern) // 只返回路径, ModTime不影响匹配
+}
+
+// Sub 返回以 dir 为根的子文件系统
+// 与 fs.Sub 不同 返回值仍是 *ModTimeFS, 子树中的文件保持原有的修改时间设定
+func (mfs *ModTimeFS) Sub(dir string) (*ModTimeFS, error) {
+	if !fs.ValidPath(dir) {
+		return nil, &fs.PathError{Op: "sub", Path: dir, Err: fs.ErrInvalid}
+	}
+	if dir == "." {
+		return mfs, nil
+	}
+	sub, err := fs.Sub(mfs, dir)
+	if err != nil {
+		return nil, err
+	}
+	// sub 中打开的文件已经由 mfs 包装, 零值时间使其沿用 mfs 的设定
+	return NewModTimeFSFromFS(sub, time.Time{}), nil
 }
 
 // 确保 ModTimeFS 实现了必要的接口
 var _ fs.FS = (*ModTimeFS)(nil)
 var _ fs.ReadDirFS = (*ModTimeFS)(nil)
 var _ fs.ReadFileFS = (*ModTimeFS)(nil)
+var _ fs.StatFS = (*ModTimeFS)(nil)
+var _ fs.GlobFS = (*ModTimeFS)(nil)
+
+var _ fs.ReadDirFile = (*modTimeFile)(nil)
+var _ io.Seeker = (*modTimeFile)(nil)
+var _ io.ReaderAt = (*modTimeFile)(nil)
+var _ io.WriterTo = (*modTimeFile)(nil)
@@ -0,0 +1,365 @@
+package modembed_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/wjqserver/modembed"
+	"github.com/wjqserver/modembed/modembedtest"
+)
+
+//go:embed testdata/static
+var testStatic embed.FS
+
+var testModTime = time.Unix(1e9, 0).UTC()
+
+func TestStatCachedAllocs(t *testing.T) {
+	mfs := modembed.New(testStatic, modembed.WithModTime(testModTime))
+	for _, name := range []string{"testdata/static/js/app.js", "testdata/static/css"} {
+		if _, err := mfs.Stat(name); err != nil {
+			t.Fatal(err)
+		}
+		allocs := testing.AllocsPerRun(100, func() {
+			mfs.Stat(name)
+		})
+		if allocs != 0 {
+			t.Errorf("Stat(%s) allocates %v times per call, want 0", name, allocs)
+		}
+	}
+}
+
+func TestStatCachedInvalidation(t *testing.T) {
+	mfs := modembed.New(testStatic, modembed.WithModTime(testModTime))
+	const name = "testdata/static/robots.txt"
+	base, err := mfs.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mfs.AddVirtual(name, []byte("virtual"), time.Time{})
+	if info, _ := mfs.Stat(name); info.Size() != int64(len("virtual")) {
+		t.Errorf("Stat size with a virtual file = %d, want %d", info.Size(), len("virtual"))
+	}
+	mfs.RemoveVirtual(name)
+	if info, _ := mfs.Stat(name); info.Size() != base.Size() {
+		t.Errorf("Stat size after RemoveVirtual = %d, want %d", info.Size(), base.Size())
+	}
+
+	later := testModTime.Add(time.Hour)
+	mfs.SetModTime(later)
+	if info, _ := mfs.Stat(name); !info.ModTime().Equal(later) {
+		t.Errorf("Stat ModTime after SetModTime = %v, want %v", info.ModTime(), later)
+	}
+}
+
+func TestDirCacheAllocs(t *testing.T) {
+	mfs := modembed.New(testStatic, modembed.WithModTime(testModTime), modembed.WithDirCache())
+	for _, dir := range []string{".", "testdata/static", "testdata/static/js"} {
+		allocs := testing.AllocsPerRun(100, func() {
+			if _, err := mfs.ReadDir(dir); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("ReadDir(%s) with WithDirCache allocates %v times per call, want 0", dir, allocs)
+		}
+	}
+}
+
+// 以下基准比较直接使用 embed.FS, 经过 ModTimeFS 包装以及启用 WithDirCache 时的开销
+func benchFS(b *testing.B, fn func(b *testing.B, fsys fs.FS)) {
+	b.Run("embed", func(b *testing.B) { fn(b, testStatic) })
+	b.Run("ModTimeFS", func(b *testing.B) { fn(b, modembed.New(testStatic, modembed.WithModTime(testModTime))) })
+	b.Run("DirCache", func(b *testing.B) {
+		fn(b, modembed.New(testStatic, modembed.WithModTime(testModTime), modembed.WithDirCache()))
+	})
+}
+
+func BenchmarkOpen(b *testing.B) {
+	benchFS(b, func(b *testing.B, fsys fs.FS) {
+		b.ReportAllocs()
+		for b.Loop() {
+			f, err := fsys.Open("testdata/static/js/app.js")
+			if err != nil {
+				b.Fatal(err)
+			}
+			f.Close()
+		}
+	})
+}
+
+func BenchmarkStat(b *testing.B) {
+	benchFS(b, func(b *testing.B, fsys fs.FS) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := fs.Stat(fsys, "testdata/static/js/app.js"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkReadDir(b *testing.B) {
+	benchFS(b, func(b *testing.B, fsys fs.FS) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := fs.ReadDir(fsys, "testdata/static"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkWalkDir(b *testing.B) {
+	benchFS(b, func(b *testing.B, fsys fs.FS) {
+		b.ReportAllocs()
+		for b.Loop() {
+			err := fs.WalkDir(fsys, ".", func(_ string, _ fs.DirEntry, err error) error { return err })
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testMapFS 返回包含源映射, 压缩文件, 子目录与空目录的文件系统, 供各个层的测试使用
+func testMapFS(t *testing.T) fstest.MapFS {
+	return fstest.MapFS{
+		"a.js":        {Data: []byte("a")},
+		"b.js":        {Data: []byte("b")},
+		"c.js":        {Data: []byte("c")},
+		"c.js.map":    {Data: []byte("{}")},
+		"d.js.gz":     {Data: gzipData(t, []byte("d"))},
+		"sub/x.js":    {Data: []byte("x")},
+		"sub/y.js":    {Data: []byte("y")},
+		"sub/y.js.gz": {Data: gzipData(t, []byte("y"))},
+		"empty":       {Mode: fs.ModeDir},
+	}
+}
+
+func TestReadDirPaging(t *testing.T) {
+	virtual := modembed.New(testMapFS(t), modembed.WithModTime(testModTime))
+	virtual.AddVirtual("sub/v.js", []byte("v"), time.Time{})
+	virtual.AddVirtual("gen/g.js", []byte("g"), time.Time{})
+
+	tests := []struct {
+		name string
+		mfs  *modembed.ModTimeFS
+		want map[string][]string // 目录 -> 条目名称
+	}{
+		{"base", modembed.New(testMapFS(t), modembed.WithModTime(testModTime)), map[string][]string{
+			".":   {"a.js", "b.js", "c.js", "c.js.map", "d.js.gz", "empty", "sub"},
+			"sub": {"x.js", "y.js", "y.js.gz"},
+		}},
+		{"virtual", virtual, map[string][]string{
+			".":   {"a.js", "b.js", "c.js", "c.js.map", "d.js.gz", "empty", "gen", "sub"},
+			"sub": {"v.js", "x.js", "y.js", "y.js.gz"},
+			"gen": {"g.js"},
+		}},
+		{"alias", modembed.New(testMapFS(t), modembed.WithModTime(testModTime), modembed.WithAlias("assets", "sub")), map[string][]string{
+			".":      {"a.js", "assets", "b.js", "c.js", "c.js.map", "d.js.gz", "empty", "sub"},
+			"assets": {"x.js", "y.js", "y.js.gz"},
+		}},
+		{"hide", modembed.New(testMapFS(t), modembed.WithModTime(testModTime), modembed.WithSourceMaps(false)), map[string][]string{
+			".":   {"a.js", "b.js", "c.js", "d.js.gz", "empty", "sub"},
+			"sub": {"x.js", "y.js", "y.js.gz"},
+		}},
+		{"decompress", modembed.New(testMapFS(t), modembed.WithModTime(testModTime), modembed.WithDecompression(".gz", modembed.GzipDecoder, 0)), map[string][]string{
+			".":   {"a.js", "b.js", "c.js", "c.js.map", "d.js", "d.js.gz", "empty", "sub"},
+			"sub": {"x.js", "y.js", "y.js.gz"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want["empty"] = nil
+			for dir, want := range tt.want {
+				checkReadDirPaging(t, "ModTimeFS", tt.mfs, dir, want)
+				if tt.name != "virtual" {
+					// 别名, 隐藏与解压的层本身也实现了 fs.ReadDirFile; 虚拟文件只存在于 ModTimeFS
+					checkReadDirPaging(t, "layer", tt.mfs.FS, dir, want)
+				}
+			}
+		})
+	}
+}
+
+// checkReadDirPaging 检查 dir 的 fs.ReadDirFile 在各种 count 下的行为
+func checkReadDirPaging(t *testing.T, label string, fsys fs.FS, dir string, want []string) {
+	t.Helper()
+	open := func() fs.ReadDirFile {
+		t.Helper()
+		f, err := fsys.Open(dir)
+		if err != nil {
+			t.Fatalf("%s: Open(%s): %v", label, dir, err)
+		}
+		rdf, ok := f.(fs.ReadDirFile)
+		if !ok {
+			t.Fatalf("%s: Open(%s) is not a fs.ReadDirFile", label, dir)
+		}
+		t.Cleanup(func() { f.Close() })
+		return rdf
+	}
+
+	for _, n := range []int{1, 2, 3, len(want) + 1} {
+		rdf := open()
+		var got []string
+		for {
+			entries, err := rdf.ReadDir(n)
+			if len(entries) > n {
+				t.Fatalf("%s: ReadDir(%d) on %s returned %d entries", label, n, dir, len(entries))
+			}
+			got = append(got, entryNames(entries)...)
+			if err == io.EOF {
+				if len(entries) != 0 {
+					t.Errorf("%s: ReadDir(%d) on %s returned entries with io.EOF", label, n, dir)
+				}
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: ReadDir(%d) on %s: %v", label, n, dir, err)
+			}
+			if len(entries) == 0 {
+				t.Fatalf("%s: ReadDir(%d) on %s returned no entries and no error", label, n, dir)
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: ReadDir(%d) pages on %s = %v, want %v", label, n, dir, got, want)
+		}
+		if entries, err := rdf.ReadDir(n); len(entries) != 0 || err != io.EOF {
+			t.Errorf("%s: ReadDir(%d) on %s after io.EOF = %d entries, %v; want io.EOF", label, n, dir, len(entries), err)
+		}
+	}
+
+	// 部分读取之后 ReadDir(-1) 返回剩余的全部条目
+	rdf := open()
+	first, err := rdf.ReadDir(1)
+	if len(want) == 0 {
+		if len(first) != 0 || err != io.EOF {
+			t.Errorf("%s: ReadDir(1) on empty %s = %d entries, %v; want io.EOF", label, dir, len(first), err)
+		}
+	} else if err != nil || len(first) != 1 {
+		t.Fatalf("%s: ReadDir(1) on %s = %d entries, %v", label, dir, len(first), err)
+	}
+	rest, err := rdf.ReadDir(-1)
+	if err != nil {
+		t.Fatalf("%s: ReadDir(-1) on %s after ReadDir(1): %v", label, dir, err)
+	}
+	if got := append(entryNames(first), entryNames(rest)...); !slices.Equal(got, want) {
+		t.Errorf("%s: ReadDir(1) + ReadDir(-1) on %s = %v, want %v", label, dir, got, want)
+	}
+	if entries, err := rdf.ReadDir(-1); len(entries) != 0 || err != nil {
+		t.Errorf("%s: second ReadDir(-1) on %s = %d entries, %v; want none and nil", label, dir, len(entries), err)
+	}
+}
+
+func entryNames(entries []fs.DirEntry) []string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// TestConformance 对各种构造方式得到的 ModTimeFS 运行 modembedtest 的一致性检查
+func TestConformance(t *testing.T) {
+	base := []string{"a.js", "b.js", "c.js", "c.js.map", "d.js.gz", "sub/x.js", "sub/y.js", "sub/y.js.gz", "empty"}
+	withVirtual := modembed.New(testMapFS(t), modembed.WithModTime(testModTime))
+	withVirtual.AddVirtual("sub/v.js", []byte("v"), time.Time{})
+	withVirtual.AddVirtual("gen/g.js", []byte("g"), time.Time{})
+	sub, err := modembed.New(testMapFS(t), modembed.WithModTime(testModTime)).Sub("sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		fsys     fs.FS
+		expected []string
+		hidden   []string // 不应出现的路径
+	}{
+		{"New", modembed.New(testMapFS(t), modembed.WithModTime(testModTime)), base, nil},
+		{"AddVirtual", withVirtual, append(base, "sub/v.js", "gen/g.js"), nil},
+		{"WithAlias", modembed.New(testMapFS(t), modembed.WithModTime(testModTime), modembed.WithAlias("assets", "sub")),
+			append(base, "assets/x.js", "assets/y.js"), nil},
+		{"WithStripPrefix", modembed.New(testMapFS(t), modembed.WithModTime(testModTime), modembed.WithStripPrefix("sub")),
+			[]string{"x.js", "y.js", "y.js.gz"}, []string{"a.js", "sub"}},
+		{"WithDecompression", modembed.New(testMapFS(t), modembed.WithModTime(testModTime), modembed.WithDecompression(".gz", modembed.GzipDecoder, 0)),
+			append(base, "d.js", "sub/y.js"), nil},
+		{"WithSourceMaps", modembed.New(testMapFS(t), modembed.WithModTime(testModTime), modembed.WithSourceMaps(false)),
+			[]string{"a.js", "c.js", "d.js.gz", "sub/x.js"}, []string{"c.js.map"}},
+		{"Sub", sub, []string{"x.js", "y.js", "y.js.gz"}, []string{"a.js", "sub"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modembedtest.Run(t, tt.fsys, testModTime, tt.expected...)
+			for _, name := range tt.hidden {
+				if _, err := fs.Stat(tt.fsys, name); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("Stat(%s) = %v, want fs.ErrNotExist", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConformanceUnion(t *testing.T) {
+	later := testModTime.Add(time.Hour)
+	top := fstest.MapFS{"b.js": {Data: []byte("b2")}, "sub/z.js": {Data: []byte("z")}, "top/t.js": {Data: []byte("t")}}
+	u := modembed.Union(
+		modembed.New(testMapFS(t), modembed.WithModTime(testModTime)),
+		modembed.New(top, modembed.WithModTime(later)),
+	)
+	// 文件与目录报告提供它的最上层的时间
+	want := func(name string) time.Time {
+		if _, err := fs.Stat(top, name); err == nil {
+			return later
+		}
+		return testModTime
+	}
+	if err := modembedtest.TestFSFunc(u, want, "a.js", "b.js", "sub/x.js", "sub/z.js", "top/t.js"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConformanceOverlay(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.js", "sub/disk.js"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("disk"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o := modembed.OverlayFS(dir, modembed.New(testMapFS(t), modembed.WithModTime(testModTime)))
+	// 磁盘上存在的路径使用磁盘上的修改时间
+	want := func(name string) time.Time {
+		if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+			return info.ModTime()
+		}
+		return testModTime
+	}
+	if err := modembedtest.TestFSFunc(o, want, "a.js", "b.js", "sub/x.js", "sub/disk.js"); err != nil {
+		t.Fatal(err)
+	}
+}
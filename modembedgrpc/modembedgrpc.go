@@ -0,0 +1,180 @@
+// Package modembedgrpc 通过 gRPC 提供 ModTimeFS 中的文件, 供不使用 HTTP 的内部工具获取嵌入的资源
+//
+//	s := grpc.NewServer()
+//	modembedgrpc.Register(s, mfs)
+//
+// 服务定义见 proto/modembed/v1/files.proto, 生成的代码位于 modembedv1;
+// Connect 客户端可以使用 gRPC 协议访问同一个服务
+package modembedgrpc
+
+//go:generate protoc -I proto --go_out=. --go_opt=module=github.com/wjqserver/modembed/modembedgrpc --go-grpc_out=. --go-grpc_opt=module=github.com/wjqserver/modembed/modembedgrpc modembed/v1/files.proto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+
+	"github.com/wjqserver/modembed"
+	"github.com/wjqserver/modembed/modembedgrpc/modembedv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultChunkSize 是未指定时每条消息携带的最大字节数
+const defaultChunkSize = 64 << 10
+
+// Server 实现 modembedv1.FileServiceServer
+type Server struct {
+	modembedv1.UnimplementedFileServiceServer
+
+	mfs       *modembed.ModTimeFS
+	chunkSize int
+}
+
+var _ modembedv1.FileServiceServer = (*Server)(nil)
+
+// NewServer 返回提供 mfs 的服务, chunkSize <= 0 时每条消息最多携带 64 KiB
+func NewServer(mfs *modembed.ModTimeFS, chunkSize int) *Server {
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	return &Server{mfs: mfs, chunkSize: chunkSize}
+}
+
+// Register 在 s 上注册使用默认设置的服务
+func Register(s grpc.ServiceRegistrar, mfs *modembed.ModTimeFS) {
+	modembedv1.RegisterFileServiceServer(s, NewServer(mfs, 0))
+}
+
+// Stat 返回文件或目录的元数据
+func (s *Server) Stat(_ context.Context, req *modembedv1.StatRequest) (*modembedv1.FileInfo, error) {
+	name, err := cleanName(req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.mfs.Stat(name)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return s.fileInfo(name, info)
+}
+
+// Get 以流的形式返回文件内容, 第一条消息带有元数据
+func (s *Server) Get(req *modembedv1.GetRequest, stream grpc.ServerStreamingServer[modembedv1.GetResponse]) error {
+	name, err := cleanName(req.GetPath())
+	if err != nil {
+		return err
+	}
+	f, err := s.mfs.Open(name)
+	if err != nil {
+		return toStatus(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return toStatus(err)
+	}
+	if info.IsDir() {
+		return status.Errorf(codes.FailedPrecondition, "%s is a directory", name)
+	}
+	fi, err := s.fileInfo(name, info)
+	if err != nil {
+		return err
+	}
+	if inm := req.GetIfNoneMatch(); inm != "" && inm == fi.GetSha256() {
+		return stream.Send(&modembedv1.GetResponse{Info: fi, NotModified: true})
+	}
+
+	buf := make([]byte, s.chunkSize)
+	resp := &modembedv1.GetResponse{Info: fi}
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 || resp.Info != nil {
+			resp.Data = buf[:n]
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			resp = &modembedv1.GetResponse{}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return toStatus(err)
+		}
+	}
+}
+
+// List 返回目录的直接子项
+func (s *Server) List(_ context.Context, req *modembedv1.ListRequest) (*modembedv1.ListResponse, error) {
+	name, err := cleanName(req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.mfs.ReadDir(name)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &modembedv1.ListResponse{Entries: make([]*modembedv1.FileInfo, 0, len(entries))}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		fi, err := s.fileInfo(path.Join(name, e.Name()), info)
+		if err != nil {
+			return nil, err
+		}
+		resp.Entries = append(resp.Entries, fi)
+	}
+	return resp, nil
+}
+
+// fileInfo 将 info 转换为消息, 普通文件带有内容哈希与 MIME 类型
+func (s *Server) fileInfo(name string, info fs.FileInfo) (*modembedv1.FileInfo, error) {
+	fi := &modembedv1.FileInfo{
+		Path:    name,
+		Size:    info.Size(),
+		ModTime: timestamppb.New(info.ModTime()),
+		IsDir:   info.IsDir(),
+	}
+	if !info.IsDir() {
+		sum, err := s.mfs.SHA256(name)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		fi.Sha256 = sum
+		fi.ContentType = mime.TypeByExtension(path.Ext(name))
+	}
+	return fi, nil
+}
+
+// cleanName 将请求中的路径转换为 fs.FS 路径, 允许开头的 "/"
+func cleanName(p string) (string, error) {
+	name := path.Clean("/" + p)[1:]
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", status.Errorf(codes.InvalidArgument, "invalid path %q", p)
+	}
+	return name, nil
+}
+
+// toStatus 将文件系统错误映射为 gRPC 状态
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, fs.ErrPermission):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, fs.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,19 @@
+package modembedotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+// globalTracer 在每次开始区间时从 otel.GetTracerProvider() 取得 Tracer,
+// 使 New(nil) 之后才设置的全局 TracerProvider 也能生效
+type globalTracer struct {
+	embedded.Tracer
+}
+
+func (globalTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.GetTracerProvider().Tracer(ScopeName).Start(ctx, name, opts...)
+}
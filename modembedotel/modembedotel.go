@@ -0,0 +1,73 @@
+// Package modembedotel 提供 modembed.Tracer 的 OpenTelemetry 实现
+//
+//	t := modembedotel.New(otel.GetTracerProvider())
+//	http.Handle("/", modembed.Handler(mfs, modembed.WithTracer(t)))
+//
+// 每个请求产生一个 modembed.serve 区间, 其下有 modembed.open, modembed.transform 与 modembed.compress 子区间,
+// 父区间取自请求的 context, 因此与 otelhttp 等中间件一起使用时资源请求出现在同一条链路中
+package modembedotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wjqserver/modembed"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName 是创建区间使用的 instrumentation scope 名称
+const ScopeName = "github.com/wjqserver/modembed"
+
+// Tracer 实现 modembed.Tracer
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+var _ modembed.Tracer = (*Tracer)(nil)
+
+// New 使用 tp 创建 Tracer, tp 为 nil 时使用 otel.GetTracerProvider() 返回的全局 TracerProvider
+func New(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		return &Tracer{tracer: globalTracer{}}
+	}
+	return &Tracer{tracer: tp.Tracer(ScopeName)}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, modembed.TraceSpan) {
+	kind := trace.SpanKindInternal
+	if name == modembed.SpanServe {
+		kind = trace.SpanKindServer
+	}
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(kind))
+	return ctx, spanAdapter{span}
+}
+
+// spanAdapter 将 trace.Span 适配为 modembed.TraceSpan
+type spanAdapter struct {
+	span trace.Span
+}
+
+func (s spanAdapter) SetAttr(key string, value any) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+func (s spanAdapter) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,105 @@
+// Package modembedprom 提供 modembed.Collector 的 Prometheus 实现
+//
+//	c := modembedprom.New("static")
+//	prometheus.MustRegister(c)
+//	http.Handle("/", modembed.Handler(mfs, modembed.WithCollector(c)))
+package modembedprom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wjqserver/modembed"
+)
+
+// otherPath 是非成功响应使用的 path 标签值, 避免任意的请求路径造成标签数量膨胀
+const otherPath = "<other>"
+
+// Collector 同时实现 modembed.Collector 与 prometheus.Collector
+type Collector struct {
+	requests *prometheus.CounterVec
+	bytes    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	open     *prometheus.HistogramVec
+	read     *prometheus.HistogramVec
+}
+
+var _ modembed.Collector = (*Collector)(nil)
+var _ prometheus.Collector = (*Collector)(nil)
+
+// New 创建一个 Collector, 指标名称以 namespace 开头 (为空时不加前缀)
+// 导出的指标:
+//   - <ns>_requests_total{path,code}: 请求数, 304 命中率为 code="304" 占全部的比例
+//   - <ns>_response_bytes_total{path}: 写出的消息体字节数
+//   - <ns>_request_duration_seconds: 请求耗时
+//   - <ns>_open_duration_seconds{result}: 打开文件的耗时, result 为 ok 或 error
+//   - <ns>_read_duration_seconds: 读取文件内容的耗时
+//
+// path 标签只对 2xx 与 304 响应使用实际路径, 其余响应使用 "<other>"
+func New(namespace string) *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "requests_total",
+			Help: "Number of requests served from the embedded file system.",
+		}, []string{"path", "code"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "response_bytes_total",
+			Help: "Number of response body bytes written.",
+		}, []string{"path"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "request_duration_seconds",
+			Help: "Time spent serving requests.", Buckets: prometheus.DefBuckets,
+		}, nil),
+		open: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "open_duration_seconds",
+			Help: "Time spent opening files.", Buckets: prometheus.ExponentialBuckets(1e-6, 4, 10),
+		}, []string{"result"}),
+		read: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "read_duration_seconds",
+			Help: "Time spent reading file contents.", Buckets: prometheus.ExponentialBuckets(1e-6, 4, 10),
+		}, nil),
+	}
+}
+
+// ObserveRequest 实现 modembed.Collector
+func (c *Collector) ObserveRequest(name string, status int, bytes int64, duration time.Duration) {
+	if status != 304 && (status < 200 || status >= 300) {
+		name = otherPath
+	}
+	c.requests.WithLabelValues(name, strconv.Itoa(status)).Inc()
+	c.bytes.WithLabelValues(name).Add(float64(bytes))
+	c.duration.WithLabelValues().Observe(duration.Seconds())
+}
+
+// ObserveOpen 实现 modembed.Collector
+func (c *Collector) ObserveOpen(name string, duration time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	c.open.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+// ObserveRead 实现 modembed.Collector
+func (c *Collector) ObserveRead(name string, bytes int64, duration time.Duration) {
+	c.read.WithLabelValues().Observe(duration.Seconds())
+}
+
+// Describe 实现 prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.bytes.Describe(ch)
+	c.duration.Describe(ch)
+	c.open.Describe(ch)
+	c.read.Describe(ch)
+}
+
+// Collect 实现 prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.bytes.Collect(ch)
+	c.duration.Collect(ch)
+	c.open.Collect(ch)
+	c.read.Collect(ch)
+}
@@ -0,0 +1,121 @@
+// Package modembedtest 提供检查 modembed 包装后的文件系统是否符合约定的辅助函数
+//
+// 除 fstest.TestFS 的通用检查外, 还检查每个 FileInfo 与 DirEntry (包括目录) 报告的修改时间
+package modembedtest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestFS 检查 fsys 中的所有文件与目录都报告 modTime
+// expected 的含义同 fstest.TestFS; modTime 通常应为 UTC 时间, 与 modembed.New 的默认设定一致
+func TestFS(fsys fs.FS, modTime time.Time, expected ...string) error {
+	return TestFSFunc(fsys, func(string) time.Time { return modTime }, expected...)
+}
+
+// TestFSFunc 与 TestFS 相同, 但每个路径期望的修改时间由 want 给出
+// 用于检查配置了 WithModTimeMap, WithModTimeRules 或 WithModTimeFunc 的文件系统
+// 报告的时间必须与期望的时间相等且位于同一时区, 因此期望 UTC 时间时也检查了 UTC 归一化
+func TestFSFunc(fsys fs.FS, want func(name string) time.Time, expected ...string) error {
+	var errs []error
+	if err := fstest.TestFS(fsys, expected...); err != nil {
+		errs = append(errs, err)
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		errs = append(errs, checkPath(fsys, name, d, want)...)
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("modembedtest: TestFS found errors:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Run 在 t 中执行 TestFS, 失败时调用 t.Fatal
+func Run(t testing.TB, fsys fs.FS, modTime time.Time, expected ...string) {
+	t.Helper()
+	if err := TestFS(fsys, modTime, expected...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkPath 检查 name 通过 Stat, File.Stat 以及目录列表报告的修改时间
+func checkPath(fsys fs.FS, name string, d fs.DirEntry, want func(string) time.Time) []error {
+	var errs []error
+	check := func(via string, got time.Time) {
+		if err := compareTime(name, via, got, want(name)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if info, err := fs.Stat(fsys, name); err != nil {
+		errs = append(errs, err)
+	} else {
+		check("fs.Stat", info.ModTime())
+	}
+	if name != "." {
+		if info, err := d.Info(); err != nil {
+			errs = append(errs, err)
+		} else {
+			check("DirEntry.Info", info.ModTime())
+		}
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return append(errs, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return append(errs, err)
+	}
+	check("File.Stat", info.ModTime())
+	if !info.IsDir() {
+		return errs
+	}
+
+	// 目录列表中的每一项也要报告各自的时间, 包括子目录
+	if dir, ok := f.(fs.ReadDirFile); ok {
+		entries, err := dir.ReadDir(-1)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, e := range entries {
+			child := path.Join(name, e.Name())
+			info, err := e.Info()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := compareTime(child, "File.ReadDir", info.ModTime(), want(child)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// compareTime 要求 got 与 want 相等且位于同一时区
+func compareTime(name, via string, got, want time.Time) error {
+	if !got.Equal(want) {
+		return fmt.Errorf("%s: %s reports ModTime %v, want %v", name, via, got, want)
+	}
+	if got.Location() != want.Location() {
+		return fmt.Errorf("%s: %s reports ModTime in %v, want %v", name, via, got.Location(), want.Location())
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package modembedtest
+
+import (
+	"io/fs"
+	"testing/fstest"
+
+	"github.com/wjqserver/modembed"
+)
+
+// Snapshot 将 fsys 的完整目录树 (路径, 内容, 权限与修改时间) 复制为 fstest.MapFS, 包括根目录 "."
+// 内容通过 fsys 读取, 因此对 ModTimeFS 得到的是转换, 虚拟文件与别名生效之后的结果,
+// 测试可以直接断言 m["app.js"].Data 或 m["app.js"].ModTime 而不必经过 HTTP
+func Snapshot(fsys fs.FS) (fstest.MapFS, error) {
+	m := make(fstest.MapFS)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		file := &fstest.MapFile{Mode: info.Mode(), ModTime: info.ModTime()}
+		if !d.IsDir() {
+			if file.Data, err = fs.ReadFile(fsys, name); err != nil {
+				return err
+			}
+		}
+		m[name] = file
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FromMapFS 以 m 为底层文件系统创建 ModTimeFS, 是 Snapshot 的逆操作
+// 未指定时间相关的选项时, 每个文件保留 m 中记录的 ModTime
+func FromMapFS(m fstest.MapFS, opts ...modembed.Option) *modembed.ModTimeFS {
+	return modembed.New(m, opts...)
+}
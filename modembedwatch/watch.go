@@ -0,0 +1,149 @@
+// Package modembedwatch 基于 fsnotify 监视开发模式下 OverlayFS 使用的磁盘目录
+//
+//	mfs := modembed.OverlayFS("static", embedded)
+//	lr := modembed.NewLiveReload()
+//	http.Handle("/__modembed/reload", lr)
+//	go modembedwatch.Watch(ctx, "static", lr.Notify)
+package modembedwatch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce 是合并连续变化的时间窗口, 编辑器保存文件时通常会产生多个事件
+const debounce = 100 * time.Millisecond
+
+// Option 配置 Watch 与 Changes
+type Option func(*options)
+
+type options struct {
+	onError func(error)
+}
+
+// WithErrorHandler 设置接收监视过程中错误 (例如事件队列溢出) 的函数, 默认使用 log.Printf 输出
+// 这些错误不会结束监视
+func WithErrorHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.onError = fn
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{onError: func(err error) { log.Printf("modembedwatch: %v", err) }}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Watch 递归监视 dir, 将变化的文件以相对于 dir 且使用 "/" 分隔的路径 (与 fs.FS 的路径形式相同) 报告给 fn
+// 短时间内的多个变化会合并成一次调用; 新建的子目录会自动加入监视
+// 监视中的错误交给 WithErrorHandler 设置的函数后继续监视; 事件队列溢出时可能丢失了变化, 此时以空的 paths 调用 fn 表示全部刷新
+// Watch 阻塞直到 ctx 结束或 fsnotify 的通道关闭 (返回 nil), 只有无法开始监视时返回错误
+func Watch(ctx context.Context, dir string, fn func(paths ...string), opts ...Option) error {
+	o := newOptions(opts)
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if err := addTree(w, dir); err != nil {
+		return err
+	}
+	return watchLoop(ctx, dir, w.Events, w.Errors, func(dir string) error { return addTree(w, dir) }, fn, o)
+}
+
+// watchLoop 是 Watch 的事件循环, add 将新建的目录加入监视
+func watchLoop(ctx context.Context, dir string, events <-chan fsnotify.Event, errs <-chan error, add func(string) error, fn func(paths ...string), o options) error {
+	pending := make(map[string]bool)
+	overflow := false // 丢失了事件, 下一次调用需要全部刷新
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-errs:
+			if !ok {
+				return nil
+			}
+			o.onError(err)
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				overflow = true
+				timer.Reset(debounce)
+			}
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					if err := add(ev.Name); err != nil {
+						o.onError(err)
+					}
+				}
+			}
+			if rel, err := filepath.Rel(dir, ev.Name); err == nil {
+				pending[filepath.ToSlash(rel)] = true
+			}
+			timer.Reset(debounce)
+		case <-timer.C:
+			if overflow {
+				overflow = false
+				clear(pending)
+				fn()
+				continue
+			}
+			paths := make([]string, 0, len(pending))
+			for p := range pending {
+				paths = append(paths, p)
+			}
+			sort.Strings(paths)
+			clear(pending)
+			fn(paths...)
+		}
+	}
+}
+
+// Changes 与 Watch 相同, 但通过通道报告变化, 通道在 ctx 结束或监视停止时关闭
+func Changes(ctx context.Context, dir string, opts ...Option) (<-chan []string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	ch := make(chan []string, 1)
+	go func() {
+		defer close(ch)
+		err := Watch(ctx, dir, func(paths ...string) {
+			select {
+			case ch <- paths:
+			case <-ctx.Done():
+			}
+		}, opts...)
+		if err != nil {
+			newOptions(opts).onError(err)
+		}
+	}()
+	return ch, nil
+}
+
+// addTree 监视 root 及其下的所有目录, fsnotify 本身不递归
+func addTree(w *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return w.Add(p)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package modembedwatch
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatchLoopContinuesAfterError(t *testing.T) {
+	dir := t.TempDir()
+	events := make(chan fsnotify.Event)
+	errs := make(chan error)
+	calls := make(chan []string, 4)
+	var reported []error
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- watchLoop(ctx, dir, events, errs, func(string) error { return nil },
+			func(paths ...string) { calls <- paths },
+			options{onError: func(err error) { reported = append(reported, err) }})
+	}()
+
+	errs <- errors.New("transient")
+	events <- fsnotify.Event{Name: filepath.Join(dir, "a.css"), Op: fsnotify.Write}
+	select {
+	case paths := <-calls:
+		if len(paths) != 1 || paths[0] != "a.css" {
+			t.Fatalf("paths = %v, want [a.css]", paths)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported after a transient error")
+	}
+
+	// 溢出之后以空的 paths 通知全部刷新
+	errs <- fsnotify.ErrEventOverflow
+	select {
+	case paths := <-calls:
+		if len(paths) != 0 {
+			t.Fatalf("paths after overflow = %v, want none", paths)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no full reload after overflow")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watchLoop returned %v", err)
+	}
+	if len(reported) != 2 {
+		t.Fatalf("reported %d errors, want 2", len(reported))
+	}
+}
@@ -0,0 +1,92 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mux 将多个文件系统挂载到不同的 URL 前缀下, 每个挂载点有各自的 Handler 配置
+//
+//	m := modembed.NewMux()
+//	m.Mount("/static/", staticFS, modembed.WithPrecompressed())
+//	m.Mount("/docs/", docsFS, modembed.WithAutoIndex())
+type Mux struct {
+	mu     sync.RWMutex
+	mounts []mount // 按前缀长度降序排列, 最长的前缀优先匹配
+}
+
+type mount struct {
+	prefix  string // 总是以 "/" 开头和结尾
+	handler http.Handler
+}
+
+// NewMux 创建一个空的 Mux
+func NewMux() *Mux {
+	return &Mux{}
+}
+
+// Mount 将 fsys 挂载到 prefix 下, 请求路径在去掉前缀后交给 Handler(fsys, opts...) 处理
+// 重复挂载同一前缀会替换之前的挂载
+func (m *Mux) Mount(prefix string, fsys *ModTimeFS, opts ...HandlerOption) {
+	m.MountHandler(prefix, Handler(fsys, opts...))
+}
+
+// MountHandler 将任意 http.Handler 挂载到 prefix 下, 请求路径在去掉前缀后交给 h 处理
+func (m *Mux) MountHandler(prefix string, h http.Handler) {
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// 复制后再修改, ServeHTTP 中持有的旧切片不受影响
+	mounts := make([]mount, 0, len(m.mounts)+1)
+	for _, mt := range m.mounts {
+		if mt.prefix != prefix {
+			mounts = append(mounts, mt)
+		}
+	}
+	mounts = append(mounts, mount{prefix: prefix, handler: h})
+	sort.SliceStable(mounts, func(i, j int) bool { return len(mounts[i].prefix) > len(mounts[j].prefix) })
+	m.mounts = mounts
+}
+
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.RLock()
+	mounts := m.mounts
+	m.mu.RUnlock()
+
+	for _, mt := range mounts {
+		if r.URL.Path+"/" == mt.prefix {
+			// /static -> /static/
+			localRedirect(w, r, r.URL.Path+"/")
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, mt.prefix) {
+			mt.handler.ServeHTTP(w, stripPrefix(r, mt.prefix))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// stripPrefix 返回去掉路径前缀 (保留结尾的 "/") 的请求副本, 行为与 http.StripPrefix 相同
+func stripPrefix(r *http.Request, prefix string) *http.Request {
+	prefix = strings.TrimSuffix(prefix, "/")
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = strings.TrimPrefix(r.URL.Path, prefix)
+	if r.URL.RawPath != "" {
+		r2.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, prefix)
+	}
+	return r2
+}
@@ -0,0 +1,81 @@
+package modembed
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"time"
+)
+
+// negativeCache 记录最近不存在的路径, 避免对同一个缺失路径反复查找底层文件系统
+type negativeCache struct {
+	ttl   time.Duration
+	cache cacheStore[*missEntry] // 操作 + "\x00" + 路径 -> 查找结果
+	bytes bool                   // 按字节计入 CacheBudget, 否则每条记录的开销为 1
+}
+
+type missEntry struct {
+	err     error
+	expires time.Time // 零值表示不过期
+}
+
+// WithNegativeCache 缓存最近 size 个不存在的路径, 之后对它们的 Open 与 Stat 直接返回缓存的错误
+// 用于应对大量请求不存在路径的爬虫; ttl > 0 时记录在 ttl 之后过期, 0 表示一直有效
+// 嵌入的文件不会变化, 缓存总是准确的; 底层为磁盘目录时可以设置较短的 ttl, 或在文件变化时调用 InvalidateMisses
+func WithNegativeCache(size int, ttl time.Duration) Option {
+	return func(o *options) {
+		o.missSize, o.missTTL = size, ttl
+	}
+}
+
+// InvalidateMisses 移除 names 及其各级父目录的缓存记录, names 为空时清空全部
+// 签名与 modembedwatch.Watch 的回调一致, 可以直接作为回调使用
+func (mfs *ModTimeFS) InvalidateMisses(names ...string) {
+	nc := mfs.misses
+	if nc == nil {
+		return
+	}
+	if len(names) == 0 {
+		nc.cache.clear()
+		return
+	}
+	for _, name := range names {
+		for dir := cleanPath(name); ; dir = path.Dir(dir) {
+			nc.cache.remove("open\x00" + dir)
+			nc.cache.remove("stat\x00" + dir)
+			if dir == "." {
+				break
+			}
+		}
+	}
+}
+
+// cachedMiss 返回 op 对 name 缓存的不存在错误, 没有记录时返回 nil
+func (mfs *ModTimeFS) cachedMiss(op, name string) error {
+	nc := mfs.misses
+	if nc == nil {
+		return nil
+	}
+	e, ok := nc.cache.get(op + "\x00" + name)
+	if !ok || (!e.expires.IsZero() && mfs.now().After(e.expires)) {
+		return nil
+	}
+	return e.err
+}
+
+// recordMiss 在 err 表示路径不存在时记录它
+func (mfs *ModTimeFS) recordMiss(op, name string, err error) {
+	nc := mfs.misses
+	if nc == nil || !errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	e := &missEntry{err: err}
+	if nc.ttl > 0 {
+		e.expires = mfs.now().Add(nc.ttl)
+	}
+	key, cost := op+"\x00"+name, int64(1)
+	if nc.bytes {
+		cost = int64(len(key)) + missEntryCost
+	}
+	nc.cache.add(key, e, cost)
+}
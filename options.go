@@ -0,0 +1,184 @@
+package modembed
+
+import (
+	"io/fs"
+	"time"
+)
+
+// Option 用于配置 New 创建的 ModTimeFS
+type Option func(*options)
+
+type options struct {
+	modTime     time.Time
+	modTimes    map[string]time.Time
+	rules       []ModTimeRule
+	timeFunc    func(string) time.Time
+	transforms  []transformRule
+	aliases     []aliasRule
+	decoders    []decoderRule
+	dirCache    bool
+	buildTime   bool
+	utc         bool
+	truncate    bool
+	zeroPolicy  ZeroTimePolicy
+	zeroLogf    func(format string, args ...any)
+	missSize    int
+	missTTL     time.Duration
+	clock       Clock
+	weakETags   *weakETagRule
+	contentTime *contentTimeRule
+	budget      *CacheBudget
+	dedupe      bool
+	fileModes   []fileModeRule
+	hidden      []string
+}
+
+// New 使用函数式选项创建 ModTimeFS, fsys 通常是 embed.FS
+// 未指定任何时间相关的选项时, ModTime 保持底层文件系统的行为
+// 只有 WithZeroTimePolicy(ZeroTimeError) 会让 New 失败, 此时 New panic, 需要处理错误时使用 NewChecked
+func New(fsys fs.FS, opts ...Option) *ModTimeFS {
+	mfs, err := NewChecked(fsys, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return mfs
+}
+
+// NewChecked 与 New 相同, 但以错误的形式报告无效的配置
+func NewChecked(fsys fs.FS, opts ...Option) (*ModTimeFS, error) {
+	o := options{utc: true, truncate: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.buildTime {
+		if t, ok := BuildTime(); ok {
+			o.modTime = t
+		} else {
+			o.modTime = o.processStart()
+		}
+	}
+	if err := o.applyZeroPolicy(); err != nil {
+		return nil, err
+	}
+	o.modTime = o.normalize(o.modTime)
+	for i := range o.rules {
+		o.rules[i].Time = o.normalize(o.rules[i].Time)
+	}
+	source := fsys
+	if len(o.decoders) > 0 {
+		fsys = withLinks(newDecompressFS(fsys, o.decoders), fsys)
+	}
+	if len(o.aliases) > 0 {
+		fsys = withLinks(newAliasFS(fsys, o.aliases), fsys)
+	}
+	if len(o.hidden) > 0 {
+		fsys = withLinks(&hideFS{fsys: fsys, patterns: o.hidden}, fsys)
+	}
+	mfs := &ModTimeFS{
+		FS:       fsys,
+		source:   source,
+		normTime: o.normalize,
+		modTimes: cleanModTimes(o.modTimes, o.normalize),
+		rules:    o.rules,
+
+		modTimeFunc: o.timeFunc,
+
+		transforms:  o.transforms,
+		clock:       o.clock,
+		weakETags:   o.weakETags,
+		contentTime: o.contentTime,
+		budget:      o.budget,
+		dedupe:      o.dedupe,
+		fileModes:   o.fileModes,
+	}
+	if o.missSize > 0 {
+		mfs.misses = &negativeCache{ttl: o.missTTL, cache: newLRU[string, *missEntry](int64(o.missSize))}
+		if o.budget != nil {
+			mfs.misses.cache, mfs.misses.bytes = newBudgetRegion[*missEntry](o.budget, CacheNegative, mfs), true
+		}
+	}
+	mfs.modTime.Store(&o.modTime)
+	if o.dirCache {
+		mfs.buildDirCache()
+	}
+	return mfs, nil
+}
+
+// WithModTime 设置所有文件统一使用的修改时间
+// 与 WithModTimeMap 同时使用时作为未命中映射的回退值
+func WithModTime(t time.Time) Option {
+	return func(o *options) {
+		o.modTime = t
+	}
+}
+
+// WithModTimeMap 设置逐路径的修改时间, 查找规则见 NewModTimeFSWithMap
+func WithModTimeMap(times map[string]time.Time) Option {
+	return func(o *options) {
+		o.modTimes = times
+	}
+}
+
+// ModTimeRule 为匹配 Glob 的路径指定修改时间
+type ModTimeRule struct {
+	Glob string    // 模式语法同 CacheRule, 不含 "/" 时匹配文件名, 否则匹配完整路径
+	Time time.Time // 匹配路径使用的修改时间
+}
+
+// WithModTimeRules 按模式为不同类别的文件设定修改时间, 第一条匹配的规则生效
+// 例如 HTML 使用部署时间, 带指纹的 js/css 使用一个固定的较早时间
+// 规则在 WithModTimeMap 之后检查, 都未命中时使用 WithModTime 的设定; 多次调用时规则会累加
+func WithModTimeRules(rules []ModTimeRule) Option {
+	return func(o *options) {
+		o.rules = append(o.rules, rules...)
+	}
+}
+
+// WithModTimeFunc 设置动态计算修改时间的函数, 在每次 Open, Stat 与 ReadDir 时以清理后的路径调用
+// 例如根据部署标记文件或功能开关决定 HTML 的时间, 而无需重新创建文件系统
+// fn 返回零值时按 WithModTimeMap, WithModTimeRules, WithModTime 的顺序继续查找
+// fn 会被频繁且并发地调用, 应当足够快并且是并发安全的
+func WithModTimeFunc(fn func(name string) time.Time) Option {
+	return func(o *options) {
+		o.timeFunc = fn
+	}
+}
+
+// WithManifest 使用清单中记录的修改时间, 等价于 WithModTimeMap(m.ModTimes())
+func WithManifest(m Manifest) Option {
+	return WithModTimeMap(m.ModTimes())
+}
+
+// WithBuildTime 使用 BuildTime 作为统一的修改时间, 无法获取时回退到进程启动时间
+// 会覆盖 WithModTime 的设定
+func WithBuildTime() Option {
+	return func(o *options) {
+		o.buildTime = true
+	}
+}
+
+// WithUTC 设置是否将所有时间转换为UTC, 默认为 true
+func WithUTC(utc bool) Option {
+	return func(o *options) {
+		o.utc = utc
+	}
+}
+
+// WithTruncateSeconds 设置是否将所有时间截断到整秒, 默认为 true
+// HTTP 日期只有秒级精度, 带有亚秒部分的 ModTime 会让某些框架中 If-Modified-Since 的比较出错
+func WithTruncateSeconds(truncate bool) Option {
+	return func(o *options) {
+		o.truncate = truncate
+	}
+}
+
+// normalize 按 WithUTC 与 WithTruncateSeconds 的设定规范化 t
+func (o *options) normalize(t time.Time) time.Time {
+	if o.utc {
+		t = t.UTC() // 确保使用UTC以保持一致性
+	}
+	if o.truncate {
+		t = t.Truncate(time.Second)
+	}
+	return t
+}
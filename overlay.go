@@ -0,0 +1,15 @@
+package modembed
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// OverlayFS 返回一个用于开发模式的文件系统
+// 文件优先从磁盘目录 diskDir 实时读取 (使用磁盘上的真实修改时间), 磁盘上不存在时回退到 embedded
+// 目录内容为两者的合并, 这样开发时可以热更新, 发布时只使用嵌入的副本, 共用一套代码路径
+func OverlayFS(diskDir string, embedded *ModTimeFS) *ModTimeFS {
+	// 零值时间使每个文件沿用所在层报告的修改时间
+	return NewModTimeFSFromFS(&layeredFS{layers: []fs.FS{os.DirFS(diskDir), embedded}}, time.Time{})
+}
@@ -0,0 +1,122 @@
+package modembed
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+)
+
+// fsLayer 是 NewChecked 在底层文件系统之上叠加的内部包装 (解压, 别名与隐藏)
+// 它们总是实现 StatFS, ReadFileFS, ReadDirFS 与 GlobFS, 并通过 fs.Stat 等函数转发到底层实现了的可选接口;
+// 这些接口存在与否不影响 fs.Stat 等函数的结果, 所以不需要探测
+// 只有 ReadLink 与 Lstat 由 withLinks 在构造时探测, 因为 fs.ReadLink 对不支持符号链接的文件系统返回错误, 接口的存在会改变结果
+// SubFS 不被转发, fs.Sub 对这些包装使用通用的实现
+type fsLayer interface {
+	fs.StatFS
+	fs.ReadFileFS
+	fs.ReadDirFS
+	fs.GlobFS
+
+	readLink(name string) (string, error)
+	lstat(name string) (fs.FileInfo, error)
+}
+
+// linkLayer 为被包装的文件系统支持符号链接 (readLinkFS) 的包装层加上 ReadLink 与 Lstat
+type linkLayer struct {
+	fsLayer
+}
+
+func (l linkLayer) ReadLink(name string) (string, error)   { return l.readLink(name) }
+func (l linkLayer) Lstat(name string) (fs.FileInfo, error) { return l.lstat(name) }
+
+// withLinks 在构造时探测 inner 是否支持符号链接, 只有支持时返回的包装才实现 ReadLink 与 Lstat,
+// 使探测 fs.ReadLinkFS 的调用方 (包括 ModTimeFS.ReadLink) 得到与底层一致的结果
+func withLinks(l fsLayer, inner fs.FS) fs.FS {
+	if _, ok := inner.(readLinkFS); ok {
+		return linkLayer{l}
+	}
+	return l
+}
+
+// 以下方法只在 inner 实现 readLinkFS 时经由 linkLayer 调用
+
+func (afs *aliasFS) readLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return afs.fsys.(readLinkFS).ReadLink(afs.resolve(name))
+}
+
+func (afs *aliasFS) lstat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := afs.fsys.(readLinkFS).Lstat(afs.resolve(name))
+	if err != nil {
+		if afs.children[name] != nil {
+			return virtualDirInfo(name), nil
+		}
+		return nil, err
+	}
+	if base := path.Base(name); name != "." && base != info.Name() {
+		return &renamedInfo{FileInfo: info, name: base}, nil
+	}
+	return info, nil
+}
+
+// Glob 的结果与 fs.Glob 回退到 ReadDir 时相同, 别名的路径无法直接交给底层的 GlobFS
+func (afs *aliasFS) Glob(pattern string) ([]string, error) {
+	return fs.Glob(struct{ fs.ReadDirFS }{afs}, pattern)
+}
+
+func (dfs *decompressFS) readLink(name string) (string, error) {
+	target, err := dfs.fsys.(readLinkFS).ReadLink(name)
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		if _, _, _, ok := dfs.blob(name); ok {
+			return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid} // 解压后的文件不是符号链接
+		}
+	}
+	return target, err
+}
+
+func (dfs *decompressFS) lstat(name string) (fs.FileInfo, error) {
+	info, err := dfs.fsys.(readLinkFS).Lstat(name)
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		return dfs.Stat(name)
+	}
+	return info, err
+}
+
+// Glob 需要包含解压后的名称, 因此总是通过 ReadDir 匹配
+func (dfs *decompressFS) Glob(pattern string) ([]string, error) {
+	return fs.Glob(struct{ fs.ReadDirFS }{dfs}, pattern)
+}
+
+func (hfs *hideFS) readLink(name string) (string, error) {
+	if hfs.hidden(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	return hfs.fsys.(readLinkFS).ReadLink(name)
+}
+
+func (hfs *hideFS) lstat(name string) (fs.FileInfo, error) {
+	if hfs.hidden(name) {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+	}
+	return hfs.fsys.(readLinkFS).Lstat(name)
+}
+
+// Glob 使用底层的 GlobFS (如果有), 再去掉被隐藏的路径
+func (hfs *hideFS) Glob(pattern string) ([]string, error) {
+	matches, err := fs.Glob(hfs.fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	visible := matches[:0]
+	for _, name := range matches {
+		if !hfs.hidden(name) {
+			visible = append(visible, name)
+		}
+	}
+	return visible, nil
+}
@@ -0,0 +1,58 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithPathValidation 设置对可疑请求路径的处理方式
+// 含有 ".." 路径段, 反斜杠, NUL 字节或编码后的分隔符 (%2F, %5C, %00) 的请求不会进入路由与文件查找
+// status 为 http.StatusNotFound (默认, 与不存在的文件无法区分) 或 http.StatusBadRequest; 0 表示关闭检查
+// embed.FS 本身不会越界, 但别名, 覆盖层与重写等功能依赖一致的路径形式
+func WithPathValidation(status int) HandlerOption {
+	return func(h *handler) {
+		h.invalidPathStatus = status
+	}
+}
+
+// rejectPath 在请求路径可疑时写出错误响应并返回 true
+func (h *handler) rejectPath(w http.ResponseWriter, r *http.Request) bool {
+	if h.invalidPathStatus == 0 || validRequestPath(r) {
+		return false
+	}
+	if h.invalidPathStatus == http.StatusBadRequest {
+		h.serveStatus(w, r, http.StatusBadRequest, "400 bad request")
+	} else {
+		h.serveError(w, r, errDenied(r.URL.Path))
+	}
+	return true
+}
+
+// validRequestPath 检查解码前后的请求路径
+func validRequestPath(r *http.Request) bool {
+	p := r.URL.Path
+	if strings.ContainsAny(p, "\\\x00") {
+		return false
+	}
+	for seg := range strings.SplitSeq(p, "/") {
+		if seg == ".." {
+			return false
+		}
+	}
+	raw := r.URL.RawPath
+	if raw == "" {
+		return true
+	}
+	for i := 0; i+2 < len(raw); i++ {
+		if raw[i] != '%' {
+			continue
+		}
+		switch strings.ToLower(raw[i+1 : i+3]) {
+		case "2f", "5c", "00":
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newPathCheckHandler(opts ...HandlerOption) http.Handler {
+	mfs := New(fstest.MapFS{
+		"app.js":        {Data: []byte("app")},
+		"static/a.css":  {Data: []byte("a")},
+		"static/b/c.js": {Data: []byte("c")},
+	}, WithModTime(testModTime))
+	return Handler(mfs, opts...)
+}
+
+var pathCheckTests = []struct {
+	target string
+	valid  bool
+	status int // 通过检查时的状态码
+}{
+	{"/app.js", true, http.StatusOK},
+	{"/static/a.css", true, http.StatusOK},
+	{"//app.js", true, http.StatusOK},
+	{"/static//a.css", true, http.StatusOK},
+	{"/static/b//c.js", true, http.StatusOK},
+	{"/..app.js", true, http.StatusNotFound},
+	{"/static/..a.css", true, http.StatusNotFound},
+	{"/..", false, 0},
+	{"/../app.js", false, 0},
+	{"/static/../app.js", false, 0},
+	{"/static/b/..", false, 0},
+	{"/%2e%2e/app.js", false, 0},
+	{"/static\\a.css", false, 0},
+	{"/static%5ca.css", false, 0},
+	{"/static%5Ca.css", false, 0},
+	{"/static%2fa.css", false, 0},
+	{"/static%2Fa.css", false, 0},
+	{"/..%2Fapp.js", false, 0},
+	{"/app.js%00", false, 0},
+	{"/app.js%00.png", false, 0},
+}
+
+func TestValidRequestPath(t *testing.T) {
+	for _, tt := range pathCheckTests {
+		r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if got := validRequestPath(r); got != tt.valid {
+			t.Errorf("validRequestPath(%q) = %v, want %v", tt.target, got, tt.valid)
+		}
+	}
+}
+
+func TestWithPathValidation(t *testing.T) {
+	modes := []struct {
+		name     string
+		opts     []HandlerOption
+		rejected int
+	}{
+		{"default", nil, http.StatusNotFound},
+		{"400", []HandlerOption{WithPathValidation(http.StatusBadRequest)}, http.StatusBadRequest},
+	}
+	for _, mode := range modes {
+		h := newPathCheckHandler(mode.opts...)
+		t.Run(mode.name, func(t *testing.T) {
+			for _, tt := range pathCheckTests {
+				want := tt.status
+				if !tt.valid {
+					want = mode.rejected
+				}
+				if w := serveTest(h, http.MethodGet, tt.target, nil); w.Code != want {
+					t.Errorf("GET %s: status = %d, want %d", tt.target, w.Code, want)
+				}
+			}
+		})
+	}
+}
+
+func FuzzValidRequestPath(f *testing.F) {
+	for _, tt := range pathCheckTests {
+		f.Add(tt.target)
+	}
+	h404 := newPathCheckHandler()
+	h400 := newPathCheckHandler(WithPathValidation(http.StatusBadRequest))
+	f.Fuzz(func(t *testing.T, target string) {
+		if !strings.HasPrefix(target, "/") {
+			target = "/" + target
+		}
+		r, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil || r.URL.Path == "" || r.URL.Host != "" || r.URL.Opaque != "" {
+			return
+		}
+		valid := validRequestPath(r)
+		if valid {
+			if strings.ContainsAny(r.URL.Path, "\\\x00") {
+				t.Fatalf("accepted %q with decoded path %q", target, r.URL.Path)
+			}
+			for seg := range strings.SplitSeq(r.URL.Path, "/") {
+				if seg == ".." {
+					t.Fatalf("accepted %q with a .. segment", target)
+				}
+			}
+		}
+
+		for _, h := range []http.Handler{h404, h400} {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code >= 500 {
+				t.Fatalf("GET %q: status %d", target, w.Code)
+			}
+		}
+		w := httptest.NewRecorder()
+		h400.ServeHTTP(w, r)
+		if (w.Code == http.StatusBadRequest) == valid {
+			t.Fatalf("GET %q with 400 mode: status %d, validRequestPath = %v", target, w.Code, valid)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package modembed
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Pick 在运行时从多组嵌入的资源中选择 key 对应的一组, 例如压缩版与调试版的前端构建
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	minFS, _ := fs.Sub(dist, "dist/min")
+//	debugFS, _ := fs.Sub(dist, "dist/debug")
+//	mfs, err := modembed.Pick(map[string]fs.FS{"min": minFS, "debug": debugFS}, os.Getenv("ASSETS"),
+//		[]string{"index.html", "app.js"}, modembed.WithBuildTime())
+//
+// 需要在编译期只嵌入其中一组时, 可以在带构建标签的文件中分别注册各自的 fs.FS
+// required 中的文件必须存在于选中的一组中且不是目录, 否则返回错误; opts 传给 NewChecked
+func Pick(sets map[string]fs.FS, key string, required []string, opts ...Option) (*ModTimeFS, error) {
+	fsys, ok := sets[key]
+	if !ok {
+		keys := make([]string, 0, len(sets))
+		for k := range sets {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return nil, fmt.Errorf("modembed: unknown asset set %q (available: %s)", key, strings.Join(keys, ", "))
+	}
+	mfs, err := NewChecked(fsys, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, name := range required {
+		info, err := mfs.Stat(cleanPath(name))
+		if err != nil || info.IsDir() {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("modembed: asset set %q is missing required files: %s", key, strings.Join(missing, ", "))
+	}
+	return mfs, nil
+}
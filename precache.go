@@ -0,0 +1,108 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrecacheEntry 是 Workbox 兼容的预缓存条目
+type PrecacheEntry struct {
+	URL      string `json:"url"`
+	Revision string `json:"revision"`
+}
+
+// Precache 根据清单生成 Workbox 兼容的预缓存列表 ([{url, revision}])
+// prefix 是文件对外提供服务时的 URL 前缀, patterns 非空时只包含匹配的文件
+// revision 取自内容哈希; 清单中没有哈希时使用修改时间与大小
+func (m Manifest) Precache(prefix string, patterns ...string) []PrecacheEntry {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	entries := make([]PrecacheEntry, 0, len(m))
+	for _, e := range m {
+		if len(patterns) > 0 && !matchAny(patterns, e.Path) {
+			continue
+		}
+		revision := e.SHA256
+		if len(revision) > 32 {
+			revision = revision[:32]
+		}
+		if revision == "" {
+			revision = fmt.Sprintf("%x-%x", e.ModTime.Unix(), e.Size)
+		}
+		entries = append(entries, PrecacheEntry{URL: prefix + e.Path, Revision: revision})
+	}
+	return entries
+}
+
+// PrecacheScript 将预缓存列表渲染为给 Service Worker 使用的脚本, 形如 self.__WB_MANIFEST = [...];
+// varName 为空时使用 __WB_MANIFEST
+func PrecacheScript(entries []PrecacheEntry, varName string) ([]byte, error) {
+	if varName == "" {
+		varName = "__WB_MANIFEST"
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "self.%s = %s;\n", varName, data)
+	return buf.Bytes(), nil
+}
+
+// WithPrecacheManifest 让 Handler 在 name (例如 "precache-manifest.js") 上提供由文件系统生成的预缓存列表
+// name 以 .js 结尾时提供 PrecacheScript 生成的脚本, 否则提供 JSON
+// prefix 与 patterns 的含义同 Manifest.Precache; 列表在首次请求时生成并缓存
+func WithPrecacheManifest(name, prefix string, patterns ...string) HandlerOption {
+	name = cleanPath(name)
+	return func(h *handler) {
+		var (
+			once    sync.Once
+			data    []byte
+			modTime time.Time
+			err     error
+		)
+		h.route(name, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			once.Do(func() {
+				var m Manifest
+				if m, err = h.fsys.Manifest(); err != nil {
+					return
+				}
+				for _, e := range m {
+					if e.ModTime.After(modTime) {
+						modTime = e.ModTime
+					}
+				}
+				entries := m.Precache(prefix, patterns...)
+				if path.Ext(name) == ".js" {
+					data, err = PrecacheScript(entries, "")
+				} else {
+					data, err = json.Marshal(entries)
+				}
+			})
+			if err != nil {
+				h.serveError(w, r, err)
+				return
+			}
+			w.Header().Set("Cache-Control", "no-cache")
+			serveBytes(w, r, name, modTime, data)
+		}))
+	}
+}
+
+// serveBytes 提供内存中的内容, 带有由内容派生的强 ETag 并支持条件请求
+func serveBytes(w http.ResponseWriter, r *http.Request, name string, modTime time.Time, data []byte) {
+	sum := sha256.Sum256(data)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	serveMemory(w, r, path.Base(name), modTime, data)
+}
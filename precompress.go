@@ -0,0 +1,181 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// precompressedSuffixes 记录内容编码对应的兄弟文件后缀
+var precompressedSuffixes = map[string]string{
+	"br":   ".br",
+	"zstd": ".zst",
+	"gzip": ".gz",
+}
+
+// defaultPrecompressed 是未指定编码时的服务端偏好顺序
+var defaultPrecompressed = []string{"br", "zstd", "gzip"}
+
+// PrecompressedIndex 是 modembed-precompress 在根目录写出的索引文件名
+// 索引是 路径 -> 已有编码列表 的 JSON 对象, 例如 {"app.js": ["br", "gzip"]}
+const PrecompressedIndex = ".precompressed.json"
+
+// WithPrecompressed 启用预压缩文件支持
+// 请求 app.js 时若存在 app.js.br / app.js.zst / app.js.gz 且客户端的 Accept-Encoding 接受对应编码
+// 则直接返回压缩后的内容, 并设置 Content-Encoding 与 Vary
+// encodings 为服务端偏好顺序, 为空时使用 br, zstd, gzip; 未知编码使用 "."+编码名 作为后缀
+// 文件系统中存在 PrecompressedIndex 时按索引选择变体, 不再逐个尝试打开兄弟文件
+func WithPrecompressed(encodings ...string) HandlerOption {
+	if len(encodings) == 0 {
+		encodings = defaultPrecompressed
+	}
+	return func(h *handler) {
+		h.encodings = append([]string(nil), encodings...)
+		h.variants = &variantIndex{}
+	}
+}
+
+// variantIndex 是延迟载入的 PrecompressedIndex
+type variantIndex struct {
+	once  sync.Once
+	files map[string][]string // 为 nil 表示没有索引
+}
+
+// load 返回索引, 索引不存在或无法解析时返回 nil
+func (vi *variantIndex) load(fsys *ModTimeFS) map[string][]string {
+	vi.once.Do(func() {
+		data, err := fsys.ReadFile(PrecompressedIndex)
+		if err != nil {
+			return
+		}
+		var files map[string][]string
+		if err := json.Unmarshal(data, &files); err == nil && files != nil {
+			vi.files = files
+		}
+	})
+	return vi.files
+}
+
+func encodingSuffix(encoding string) string {
+	if suffix, ok := precompressedSuffixes[encoding]; ok {
+		return suffix
+	}
+	return "." + encoding
+}
+
+// parseAcceptEncoding 解析 Accept-Encoding, 返回编码到 q 值的映射
+func parseAcceptEncoding(header string) map[string]float64 {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					q = f
+				}
+			}
+		}
+		accepted[coding] = q
+	}
+	return accepted
+}
+
+// negotiateEncodings 按客户端 q 值 (高者优先) 与服务端偏好顺序返回可接受的编码列表
+func negotiateEncodings(header string, offered []string) []string {
+	if header == "" || len(offered) == 0 {
+		return nil
+	}
+	accepted := parseAcceptEncoding(header)
+	type candidate struct {
+		encoding string
+		q        float64
+	}
+	var candidates []candidate
+	for _, enc := range offered {
+		q, ok := accepted[enc]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > 0 {
+			candidates = append(candidates, candidate{enc, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	result := make([]string, len(candidates))
+	for i, c := range candidates {
+		result[i] = c.encoding
+	}
+	return result
+}
+
+// openPrecompressed 为 name 查找客户端可接受的预压缩兄弟文件
+// 返回选中的编码, 变体路径, 已经打开的文件及其大小, 没有可用变体时返回空编码
+func (h *handler) openPrecompressed(r *http.Request, name string) (string, string, fs.File, int64) {
+	index := h.variants.load(h.fsys)
+	for _, enc := range negotiateEncodings(r.Header.Get("Accept-Encoding"), h.encodings) {
+		if index != nil && !slices.Contains(index[name], enc) {
+			continue
+		}
+		variant := name + encodingSuffix(enc)
+		f, err := h.fsys.Open(variant)
+		if err != nil {
+			continue
+		}
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			f.Close()
+			continue
+		}
+		return enc, variant, f, info.Size()
+	}
+	return "", "", nil, 0
+}
+
+// encodingWriter 在写出状态码时才设置 Content-Encoding
+// http.ServeContent 在已设置 Content-Encoding 时不会写出 Content-Length, 延后设置可以让 Content-Range 反映压缩后的表示
+// 完整响应 (200) 的 Content-Length 由 size 显式给出, GET 与 HEAD 都报告所选表示 (压缩后) 的长度
+type encodingWriter struct {
+	http.ResponseWriter
+	encoding    string
+	size        int64 // 所选表示的完整长度
+	wroteHeader bool
+}
+
+func (ew *encodingWriter) WriteHeader(code int) {
+	if !ew.wroteHeader {
+		ew.wroteHeader = true
+		switch code {
+		case http.StatusOK:
+			ew.Header().Set("Content-Length", strconv.FormatInt(ew.size, 10))
+			ew.Header().Set("Content-Encoding", ew.encoding)
+		case http.StatusPartialContent:
+			ew.Header().Set("Content-Encoding", ew.encoding)
+		case http.StatusRequestedRangeNotSatisfiable:
+			// Content-Range 中的长度是压缩后表示的长度, 同时告知客户端所指的编码
+			ew.Header().Set("Content-Encoding", ew.encoding)
+		}
+	}
+	ew.ResponseWriter.WriteHeader(code)
+}
+
+func (ew *encodingWriter) Write(p []byte) (int, error) {
+	if !ew.wroteHeader {
+		ew.WriteHeader(http.StatusOK)
+	}
+	return ew.ResponseWriter.Write(p)
+}
+
+func (ew *encodingWriter) Unwrap() http.ResponseWriter { return ew.ResponseWriter }
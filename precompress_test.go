@@ -0,0 +1,110 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompressedRepresentations(t *testing.T) {
+	original := []byte(strings.Repeat("console.log('modembed');\n", 200))
+	brotli := []byte("not really brotli, but served as is")
+	gz := gzipBytes(t, original)
+	onTheFly, err := gzipContent(context.Background(), bytes.NewReader(original))
+	if err != nil || onTheFly == nil {
+		t.Fatalf("gzipContent = %v, %v", onTheFly, err)
+	}
+
+	mfs := New(fstest.MapFS{
+		"app.js":    {Data: original},
+		"app.js.br": {Data: brotli},
+		"app.js.gz": {Data: gz},
+		"lib.js":    {Data: original},
+	}, WithModTime(testModTime))
+	h := Handler(mfs, WithPrecompressed("br", "gzip"), WithCompression(0, 0))
+
+	tests := []struct {
+		name     string
+		target   string
+		accept   string
+		encoding string
+		body     []byte
+	}{
+		{"precompressed br", "/app.js", "gzip, br", "br", brotli},
+		{"precompressed gzip", "/app.js", "gzip", "gzip", gz},
+		{"on-the-fly gzip", "/lib.js", "gzip, br", "gzip", onTheFly},
+		{"identity", "/app.js", "identity", "", original},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accept := http.Header{"Accept-Encoding": {tt.accept}}
+			size := strconv.Itoa(len(tt.body))
+			for _, method := range []string{http.MethodGet, http.MethodHead} {
+				w := serveTest(h, method, tt.target, accept)
+				if w.Code != http.StatusOK {
+					t.Fatalf("%s status = %d, want 200", method, w.Code)
+				}
+				checkHeader(t, method, w.Header(), "Content-Length", size)
+				checkHeader(t, method, w.Header(), "Content-Encoding", tt.encoding)
+				checkHeader(t, method, w.Header(), "Vary", "Accept-Encoding")
+				checkHeader(t, method, w.Header(), "Content-Type", "text/javascript; charset=utf-8")
+				if method == http.MethodGet && !bytes.Equal(w.Body.Bytes(), tt.body) {
+					t.Errorf("GET body is %d bytes, want the %d byte representation", w.Body.Len(), len(tt.body))
+				}
+				if method == http.MethodHead && w.Body.Len() != 0 {
+					t.Errorf("HEAD response has a %d byte body", w.Body.Len())
+				}
+			}
+			if tt.encoding == "" {
+				return
+			}
+
+			// 范围针对所选的压缩表示
+			w := serveTest(h, http.MethodGet, tt.target, http.Header{"Accept-Encoding": {tt.accept}, "Range": {"bytes=0-9"}})
+			if w.Code != http.StatusPartialContent {
+				t.Fatalf("range status = %d, want 206", w.Code)
+			}
+			checkHeader(t, "206", w.Header(), "Content-Length", "10")
+			checkHeader(t, "206", w.Header(), "Content-Range", fmt.Sprintf("bytes 0-9/%d", len(tt.body)))
+			checkHeader(t, "206", w.Header(), "Content-Encoding", tt.encoding)
+			checkHeader(t, "206", w.Header(), "Vary", "Accept-Encoding")
+			if !bytes.Equal(w.Body.Bytes(), tt.body[:10]) {
+				t.Errorf("206 body = %q, want %q", w.Body.Bytes(), tt.body[:10])
+			}
+
+			w = serveTest(h, http.MethodGet, tt.target, http.Header{"Accept-Encoding": {tt.accept}, "Range": {fmt.Sprintf("bytes=%d-", len(tt.body))}})
+			if w.Code != http.StatusRequestedRangeNotSatisfiable {
+				t.Fatalf("unsatisfiable range status = %d, want 416", w.Code)
+			}
+			checkHeader(t, "416", w.Header(), "Content-Range", fmt.Sprintf("bytes */%d", len(tt.body)))
+			checkHeader(t, "416", w.Header(), "Content-Encoding", tt.encoding)
+			checkHeader(t, "416", w.Header(), "Vary", "Accept-Encoding")
+		})
+	}
+}
+
+func checkHeader(t *testing.T, label string, header http.Header, key, want string) {
+	t.Helper()
+	if got := header.Get(key); got != want {
+		t.Errorf("%s %s = %q, want %q", label, key, got, want)
+	}
+}
@@ -0,0 +1,102 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// PreloadStats 描述 Preload 预热的内容
+type PreloadStats struct {
+	Files           int           // 读取并计算了 ETag 的文件数
+	Bytes           int64         // 这些文件的总字节数
+	Compressed      int           // 预先压缩的文件数, 未启用 WithCompression 时为 0
+	CompressedBytes int64         // 压缩结果的总字节数
+	Duration        time.Duration // 预热花费的时间
+}
+
+// Preload 在启动时预热 h 提供的匹配 globs 的文件 (为空时为全部文件), 避免首个请求承担读取与压缩的开销
+// 每个文件会被完整读取一次, 计算并缓存 ETag (以及转换的结果); 启用了 WithCompression 时同时压缩并写入压缩缓存
+// 文件系统中存在 PreloadHintsFile 时先按其中的顺序预热列出的文件, 再预热其余文件
+// 被 WithDeny 拒绝的文件会被跳过; h 必须是 Handler 返回的 http.Handler
+func Preload(h http.Handler, globs ...string) (PreloadStats, error) {
+	mh, ok := h.(*handler)
+	if !ok {
+		return PreloadStats{}, errors.New("modembed: Preload requires a handler returned by Handler")
+	}
+	start := mh.fsys.now()
+	var names []string
+	err := fs.WalkDir(mh.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if (len(globs) > 0 && !matchAny(globs, name)) || mh.denied(name) {
+			return nil
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return PreloadStats{}, err
+	}
+	if hints := preloadHints(mh.fsys); len(hints) > 0 {
+		rank := make(map[string]int, len(hints))
+		for i, name := range hints {
+			if _, ok := rank[cleanPath(name)]; !ok {
+				rank[cleanPath(name)] = i
+			}
+		}
+		slices.SortStableFunc(names, func(a, b string) int {
+			ra, oka := rank[a]
+			rb, okb := rank[b]
+			switch {
+			case oka && okb:
+				return ra - rb
+			case oka:
+				return -1
+			case okb:
+				return 1
+			}
+			return 0
+		})
+	}
+
+	var stats PreloadStats
+	for _, name := range names {
+		if err := mh.preload(name, &stats); err != nil {
+			stats.Duration = mh.fsys.since(start)
+			return stats, err
+		}
+	}
+	stats.Duration = mh.fsys.since(start)
+	return stats, nil
+}
+
+// preload 预热单个文件并累计到 stats
+func (h *handler) preload(name string, stats *PreloadStats) error {
+	if _, err := h.fsys.digest(name); err != nil {
+		return err
+	}
+	info, err := h.fsys.Stat(name)
+	if err != nil {
+		return err
+	}
+	stats.Files++
+	stats.Bytes += info.Size()
+	if c := h.compression; c != nil && info.Size() >= c.minSize && compressible(h.contentType(name)) {
+		e, err := c.entry(context.Background(), h.fsys, name, info)
+		if err != nil {
+			return err
+		}
+		if e.data != nil {
+			stats.Compressed++
+			stats.CompressedBytes += int64(len(e.data))
+		}
+	}
+	return nil
+}
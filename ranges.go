@@ -0,0 +1,72 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"net/http"
+	"strings"
+)
+
+// defaultMaxRanges 是单个请求中允许的最大范围数, 与 Apache 的 MaxRanges 思路相同
+const defaultMaxRanges = 32
+
+// WithMaxRanges 设置单个 Range 请求中允许的最大范围数, 默认为 32
+// 超过限制的请求忽略 Range 头, 返回完整内容 (200), 避免大量细碎范围造成的放大
+// n < 0 表示不限制, n == 0 表示禁用范围请求
+//
+// 范围请求的处理 (单个范围返回 206, 多个范围返回 multipart/byteranges,
+// 无法满足的范围返回带有 Content-Range: bytes */size 的 416) 由 http.ServeContent 完成;
+// ModTimeFS 打开的文件总是可以 Seek (必要时读入内存), 因此对任何底层文件系统都成立
+func WithMaxRanges(n int) HandlerOption {
+	return func(h *handler) {
+		h.maxRanges = n
+	}
+}
+
+// limitRanges 在 r 的 Range 头语法无效或范围数超过限制时返回去掉 Range 头的请求副本
+// 按 RFC 9110 语法无效的 Range 被忽略而不是返回 416
+func (h *handler) limitRanges(r *http.Request) *http.Request {
+	rng := r.Header.Get("Range")
+	if rng == "" {
+		return r
+	}
+	n, ok := countRanges(rng)
+	if ok && (h.maxRanges < 0 || n <= h.maxRanges) {
+		return r
+	}
+	r = r.Clone(r.Context())
+	r.Header.Del("Range")
+	r.Header.Del("If-Range")
+	return r
+}
+
+// countRanges 校验 bytes 单位的 Range 头并返回其中的范围数
+// 每个范围形如 first-last, first- 或 -suffix, 各部分均为十进制数字
+func countRanges(header string) (int, bool) {
+	specs, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, false
+	}
+	n := 0
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue // RFC 9110 允许空的列表元素
+		}
+		first, last, ok := strings.Cut(spec, "-")
+		if !ok || (first == "" && last == "") || !isDigits(first) || !isDigits(last) {
+			return 0, false
+		}
+		n++
+	}
+	return n, n > 0
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package modembed
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// readLinkFS 与 Go 1.25 的 fs.ReadLinkFS 相同, 在这里定义以便在更早的版本中使用
+type readLinkFS interface {
+	ReadLink(name string) (string, error)
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// ReadLink 返回符号链接 name 的目标, 底层文件系统不支持符号链接时返回 fs.ErrInvalid
+// 与 fs.ReadLinkFS 一致, 便于探测该接口的工具透过包装继续工作
+func (mfs *ModTimeFS) ReadLink(name string) (string, error) {
+	if rl, ok := mfs.FS.(readLinkFS); ok && mfs.virtualFileOf(name) == nil {
+		return rl.ReadLink(name)
+	}
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, err := mfs.rawStat(name); err != nil {
+		var pe *fs.PathError
+		if errors.As(err, &pe) {
+			// 复制一份再改写 Op, 原错误可能被缺失缓存共享
+			return "", &fs.PathError{Op: "readlink", Path: pe.Path, Err: pe.Err}
+		}
+		return "", err
+	}
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid} // 不是符号链接
+}
+
+// Lstat 与 Stat 相同, 但 name 为符号链接时返回链接本身的信息, 修改时间同样按设定覆盖
+// 底层文件系统不支持符号链接时等同于 Stat
+func (mfs *ModTimeFS) Lstat(name string) (fs.FileInfo, error) {
+	rl, ok := mfs.FS.(readLinkFS)
+	if !ok || mfs.virtualFileOf(name) != nil {
+		return mfs.Stat(name)
+	}
+	info, err := rl.Lstat(name)
+	if err != nil {
+		if mfs.virtualChildren(name) != nil {
+			return mfs.Stat(name)
+		}
+		return nil, err
+	}
+	return mfs.wrapInfo(name, info)
+}
@@ -0,0 +1,7 @@
+//go:build go1.25
+
+package modembed
+
+import "io/fs"
+
+var _ fs.ReadLinkFS = (*ModTimeFS)(nil)
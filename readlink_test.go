@@ -0,0 +1,35 @@
+package modembed
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadLinkError(t *testing.T) {
+	mfs := New(fstest.MapFS{"a.txt": {Data: []byte("a")}}, WithNegativeCache(16, 0))
+	tests := []struct {
+		name string
+		want error
+		msg  string
+	}{
+		{"a.txt", fs.ErrInvalid, "readlink a.txt: invalid argument"},
+		{"missing.txt", fs.ErrNotExist, "readlink missing.txt: file does not exist"},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 2; i++ { // 第二次经过缺失缓存
+			_, err := mfs.ReadLink(tt.name)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ReadLink(%s) = %v, want %v", tt.name, err, tt.want)
+			}
+			if err != nil && err.Error() != tt.msg {
+				t.Errorf("ReadLink(%s) error = %q, want %q", tt.name, err, tt.msg)
+			}
+		}
+	}
+	var pe *fs.PathError
+	if _, err := mfs.Stat("missing.txt"); !errors.As(err, &pe) || pe.Op == "readlink" {
+		t.Errorf("Stat after ReadLink = %v, want the original error", err)
+	}
+}
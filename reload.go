@@ -0,0 +1,120 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// liveReloadHeartbeat 是 SSE 连接上注释行的发送间隔, 防止代理因空闲断开连接
+const liveReloadHeartbeat = 30 * time.Second
+
+// LiveReloadScript 是一段浏览器脚本, 连接到挂载在 /__modembed/reload 的 LiveReload 并在文件变化时刷新页面
+// 可以在开发模式下插入到 HTML 中, 或通过 WithTransform 注入
+const LiveReloadScript = `<script>new EventSource("/__modembed/reload").addEventListener("change", function () { location.reload() })</script>`
+
+// LiveReload 是一个 Server-Sent Events 端点, 将 Notify 报告的文件变化广播给所有连接的浏览器
+// 每次变化发送一个 "change" 事件, 数据为以换行分隔的路径; 可以被多个 goroutine 并发使用
+//
+//	lr := modembed.NewLiveReload()
+//	http.Handle("/__modembed/reload", lr)
+//	go modembedwatch.Watch(ctx, "static", lr.Notify)
+type LiveReload struct {
+	mu      sync.Mutex // 保护 clients 以及每个连接的 pending
+	clients map[*reloadClient]struct{}
+}
+
+// reloadClient 是一个连接尚未发送的变化
+type reloadClient struct {
+	wake    chan struct{} // 容量为 1, 有待发送的变化时非空
+	pending []string      // 按首次出现的顺序, 不重复
+	seen    map[string]bool
+}
+
+// NewLiveReload 创建一个没有连接的 LiveReload
+func NewLiveReload() *LiveReload {
+	return &LiveReload{clients: make(map[*reloadClient]struct{})}
+}
+
+// Notify 向所有连接的浏览器广播 paths 发生了变化
+// 不会阻塞: 连接来不及发送时, 变化的路径会累积并与下一次一起发送
+func (lr *LiveReload) Notify(paths ...string) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	for c := range lr.clients {
+		for _, p := range paths {
+			if !c.seen[p] {
+				c.seen[p] = true
+				c.pending = append(c.pending, p)
+			}
+		}
+		select {
+		case c.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// take 取出 c 累积的变化
+func (lr *LiveReload) take(c *reloadClient) []string {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	paths := c.pending
+	c.pending, c.seen = nil, make(map[string]bool)
+	return paths
+}
+
+// sseLineReplacer 去掉路径中会结束 SSE 数据行的字符
+var sseLineReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+func (lr *LiveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "500 streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	c := &reloadClient{wake: make(chan struct{}, 1), seen: make(map[string]bool)}
+	lr.mu.Lock()
+	lr.clients[c] = struct{}{}
+	lr.mu.Unlock()
+	defer func() {
+		lr.mu.Lock()
+		delete(lr.clients, c)
+		lr.mu.Unlock()
+	}()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no") // 关闭 nginx 的响应缓冲
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(liveReloadHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case <-c.wake:
+			paths := lr.take(c)
+			fmt.Fprint(w, "event: change\n")
+			for _, p := range paths {
+				// \r, \n 与 \r\n 都会结束 SSE 的一行
+				fmt.Fprintf(w, "data: %s\n", sseLineReplacer.Replace(p))
+			}
+			if len(paths) == 0 {
+				fmt.Fprint(w, "data:\n")
+			}
+			fmt.Fprint(w, "\n")
+		}
+		flusher.Flush()
+	}
+}
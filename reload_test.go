@@ -0,0 +1,75 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEvent 读取下一个 SSE 事件的 data 行, 跳过注释
+func readEvent(t *testing.T, br *bufio.Reader) []string {
+	t.Helper()
+	var data []string
+	inEvent := false
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && inEvent:
+			return data
+		case strings.HasPrefix(line, "event: "):
+			inEvent = true
+		case strings.HasPrefix(line, "data: "):
+			if strings.ContainsRune(line, '\r') {
+				t.Fatalf("data line contains \\r: %q", line)
+			}
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func TestLiveReloadMergesPendingPaths(t *testing.T) {
+	lr := NewLiveReload()
+	srv := httptest.NewServer(lr)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	br := bufio.NewReader(resp.Body)
+	if line, _ := br.ReadString('\n'); line != ": connected\n" {
+		t.Fatalf("first line = %q", line)
+	}
+
+	// 在连接发送之前连续通知, 路径应当合并到同一个或随后的事件中, 不能丢失
+	lr.Notify("a.css")
+	lr.Notify("b.js", "a.css")
+	lr.Notify("evil\r.js")
+
+	got := map[string]bool{}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(got) < 3 && time.Now().Before(deadline) {
+		for _, p := range readEvent(t, br) {
+			got[p] = true
+		}
+	}
+	for _, want := range []string{"a.css", "b.js", "evil.js"} {
+		if !got[want] {
+			t.Errorf("path %q not delivered, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// DefaultDenyPatterns 是常见的不应对外暴露的文件: 隐藏文件与目录, source map 以及编辑器备份文件
+var DefaultDenyPatterns = []string{".*", ".*/", "*.map", "*~", "*.bak", "*.swp"}
+
+// WithDeny 拒绝访问匹配 patterns 的路径, 被拒绝的路径与不存在的文件一样返回 404, 也不会出现在目录列表中
+// 模式语法同 CachePolicy; 以 "/" 结尾的模式匹配目录, 目录下的所有路径都会被拒绝, 例如 ".git/"
+// 多次调用时模式会累加
+func WithDeny(patterns ...string) HandlerOption {
+	return func(h *handler) {
+		h.deny = append(h.deny, patterns...)
+	}
+}
+
+// denied 判断 name 是否匹配拒绝规则
+func (h *handler) denied(name string) bool {
+	if len(h.deny) == 0 || name == "." {
+		return false
+	}
+	for _, pattern := range h.deny {
+		if dirPattern, ok := strings.CutSuffix(pattern, "/"); ok {
+			// 目录模式检查每一级父目录以及路径本身
+			for dir := name; dir != "."; dir = path.Dir(dir) {
+				if matchPattern(dirPattern, dir) {
+					return true
+				}
+			}
+			continue
+		}
+		if matchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// errDenied 返回一个被 serveError 映射为 404 的错误
+func errDenied(name string) error {
+	return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// DefaultSecurityHeaders 返回一组常用的安全响应头, 可以在修改后传给 WithSecurityHeaders
+func DefaultSecurityHeaders() http.Header {
+	return http.Header{
+		"X-Content-Type-Options": {"nosniff"},
+		"X-Frame-Options":        {"SAMEORIGIN"},
+		"Referrer-Policy":        {"strict-origin-when-cross-origin"},
+	}
+}
+
+// WithSecurityHeaders 为所有响应 (包括错误响应) 附加 headers, 例如 Content-Security-Policy
+func WithSecurityHeaders(headers http.Header) HandlerOption {
+	headers = headers.Clone()
+	return func(h *handler) {
+		if h.securityHeaders == nil {
+			h.securityHeaders = make(http.Header)
+		}
+		for k, v := range headers {
+			h.securityHeaders[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+}
@@ -0,0 +1,106 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 签名 URL 使用的查询参数
+const (
+	SignedURLExpires   = "expires"
+	SignedURLSignature = "signature"
+)
+
+// URLSigner 生成与验证带 HMAC-SHA256 签名, 会过期的 URL, 用于在不另设存储的情况下分享嵌入的下载文件
+//
+//	signer := modembed.NewURLSigner(key, "/downloads/")
+//	mux.Handle("/downloads/", http.StripPrefix("/downloads", modembed.Handler(mfs, modembed.WithSignedURLs(signer))))
+//	link := signer.SignURL("/downloads/release.zip", time.Now().Add(24*time.Hour))
+//
+// 签名覆盖 Handler 看到的文件路径与过期时间, 不覆盖其他查询参数
+type URLSigner struct {
+	key    []byte
+	prefix string
+}
+
+// NewURLSigner 使用 key 创建 URLSigner, key 应当是足够长的随机字节
+// prefix 是 Handler 挂载的 URL 前缀 (例如通过 http.StripPrefix 去掉的 "/downloads/"), 没有时为空
+func NewURLSigner(key []byte, prefix string) *URLSigner {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &URLSigner{key: append([]byte(nil), key...), prefix: prefix}
+}
+
+// SignURL 返回 urlPath 加上过期时间与签名查询参数后的 URL, expires 为零值时链接不会过期
+// urlPath 是客户端请求的完整路径, 包括 NewURLSigner 的前缀
+func (s *URLSigner) SignURL(urlPath string, expires time.Time) string {
+	var exp int64
+	if !expires.IsZero() {
+		exp = expires.Unix()
+	}
+	q := url.Values{}
+	q.Set(SignedURLExpires, strconv.FormatInt(exp, 10))
+	q.Set(SignedURLSignature, s.sign(s.name(urlPath), exp))
+	sep := "?"
+	if strings.Contains(urlPath, "?") {
+		sep = "&"
+	}
+	return urlPath + sep + q.Encode()
+}
+
+// name 返回 urlPath 去掉前缀之后, Handler 看到的清理后的路径
+func (s *URLSigner) name(urlPath string) string {
+	urlPath, _, _ = strings.Cut(urlPath, "?")
+	urlPath = "/" + strings.TrimPrefix(urlPath, "/")
+	if prefix := "/" + strings.TrimPrefix(s.prefix, "/"); s.prefix != "" && strings.HasPrefix(urlPath, prefix) {
+		urlPath = urlPath[len(prefix):]
+	}
+	return cleanPath(urlPath)
+}
+
+func (s *URLSigner) sign(name string, exp int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(exp, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// valid 判断 query 中的签名对清理后的路径 name 在 now 时是否有效
+func (s *URLSigner) valid(name string, query url.Values, now time.Time) bool {
+	exp, err := strconv.ParseInt(query.Get(SignedURLExpires), 10, 64)
+	if err != nil || (exp != 0 && now.Unix() > exp) {
+		return false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(query.Get(SignedURLSignature))
+	if err != nil {
+		return false
+	}
+	want, _ := base64.RawURLEncoding.DecodeString(s.sign(name, exp))
+	return hmac.Equal(got, want)
+}
+
+// WithSignedURLs 要求对匹配 patterns (为空时为全部文件) 的路径的请求带有 s 签发的有效签名, 否则返回 403
+// 签名在 WithAuthorize 之前检查, 两者都设置时都需要通过
+func WithSignedURLs(s *URLSigner, patterns ...string) HandlerOption {
+	return func(h *handler) {
+		h.signer, h.signedPatterns = s, patterns
+	}
+}
+
+// signatureValid 判断请求是否满足 WithSignedURLs 的要求
+func (h *handler) signatureValid(r *http.Request, name string) bool {
+	if h.signer == nil || (len(h.signedPatterns) > 0 && !matchAny(h.signedPatterns, name)) {
+		return true
+	}
+	return h.signer.valid(name, r.URL.Query(), h.fsys.now())
+}
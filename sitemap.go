@@ -0,0 +1,127 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultRobots 是 AddRobots 在 tmpl 为空时使用的 robots.txt 模板
+const DefaultRobots = `User-agent: *
+Allow: /
+{{with .Sitemap}}
+Sitemap: {{.}}
+{{end}}`
+
+// RobotsData 是执行 robots.txt 模板时的数据
+type RobotsData struct {
+	BaseURL string // 站点的根 URL, 例如 "https://example.com/"
+	Sitemap string // sitemap.xml 的完整 URL, 为空时不输出 Sitemap 行
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Sitemap 根据文件系统中匹配 patterns (为空时为 *.html) 的文件生成 sitemap.xml
+// baseURL 是站点的根 URL; index.html 对应所在目录的 URL, 例如 docs/index.html -> https://example.com/docs/
+// lastmod 取自文件的修改时间 (即 ModTimeFS 的设定); 以 "." 开头的文件与目录会被跳过
+// 返回的 time.Time 是所有页面中最新的修改时间
+func (mfs *ModTimeFS) Sitemap(baseURL string, patterns ...string) ([]byte, time.Time, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"*.html"}
+	}
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/") + "/")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	var latest time.Time
+	err = fs.WalkDir(mfs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !matchAny(patterns, name) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		ref := name
+		if path.Base(name) == indexPage {
+			ref = strings.TrimSuffix(name, indexPage)
+		}
+		loc := base.ResolveReference(&url.URL{Path: ref})
+		u := sitemapURL{Loc: loc.String()}
+		if t := info.ModTime(); !t.IsZero() {
+			u.LastMod = t.UTC().Format(time.RFC3339)
+			if t.After(latest) {
+				latest = t
+			}
+		}
+		set.URLs = append(set.URLs, u)
+		return nil
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "\t")
+	if err := enc.Encode(set); err != nil {
+		return nil, time.Time{}, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), latest, nil
+}
+
+// AddSitemap 生成 sitemap.xml 并注册为虚拟文件 sitemap.xml, 参数同 Sitemap
+// 修改时间为最新页面的修改时间; 文件系统中已有的 sitemap.xml 会被遮蔽
+func (mfs *ModTimeFS) AddSitemap(baseURL string, patterns ...string) error {
+	data, latest, err := mfs.Sitemap(baseURL, patterns...)
+	if err != nil {
+		return err
+	}
+	mfs.AddVirtual("sitemap.xml", data, latest)
+	return nil
+}
+
+// AddRobots 使用 text/template 模板 tmpl (为空时为 DefaultRobots) 生成 robots.txt 并注册为虚拟文件
+// 修改时间为 ModTimeFS 的统一修改时间; 文件系统中已有的 robots.txt 会被遮蔽
+func (mfs *ModTimeFS) AddRobots(tmpl string, data RobotsData) error {
+	if tmpl == "" {
+		tmpl = DefaultRobots
+	}
+	t, err := template.New("robots.txt").Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+	mfs.AddVirtual("robots.txt", buf.Bytes(), mfs.ModTime())
+	return nil
+}
@@ -0,0 +1,131 @@
+package modembed
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+)
+
+// sourceMapPatterns 是 WithSourceMaps(false) 去掉 sourceMappingURL 注释的文件
+var sourceMapPatterns = []string{"*.js", "*.mjs", "*.cjs", "*.css"}
+
+// WithSourceMaps 控制是否暴露嵌入的 source map, 使生产构建可以把 .map 文件一起嵌入, 只在调试时提供
+// enabled 为 false 时 *.map 文件对 Open, Stat, ReadFile 与 ReadDir 都不可见,
+// 并通过转换去掉 JavaScript 与 CSS 末尾的 //# sourceMappingURL=... 或 /*# sourceMappingURL=... */ 注释
+// 转换后的文件不再使用预压缩变体 (见 WithTransform), 需要同时使用预压缩时应在构建阶段去掉这些注释
+// enabled 为 true 时没有效果, 例如 WithSourceMaps(os.Getenv("DEBUG") != "")
+func WithSourceMaps(enabled bool) Option {
+	return func(o *options) {
+		if enabled {
+			return
+		}
+		o.hidden = append(o.hidden, "*.map")
+		for _, pattern := range sourceMapPatterns {
+			o.transforms = append(o.transforms, transformRule{pattern: pattern, fn: stripSourceMappingURL})
+		}
+	}
+}
+
+// stripSourceMappingURL 去掉 data 末尾的 sourceMappingURL 注释行, 没有时原样返回
+func stripSourceMappingURL(_ string, data []byte) ([]byte, error) {
+	for {
+		trimmed := bytes.TrimRight(data, " \t\r\n")
+		start := bytes.LastIndexByte(trimmed, '\n') + 1
+		line := bytes.TrimSpace(trimmed[start:])
+		if !isSourceMapComment(line) {
+			return data, nil
+		}
+		data = trimmed[:start]
+	}
+}
+
+// isSourceMapComment 判断 line 是否是 sourceMappingURL 注释, 包括旧的 //@ 语法
+func isSourceMapComment(line []byte) bool {
+	switch {
+	case bytes.HasPrefix(line, []byte("//# sourceMappingURL=")), bytes.HasPrefix(line, []byte("//@ sourceMappingURL=")):
+		return true
+	case bytes.HasPrefix(line, []byte("/*# sourceMappingURL=")), bytes.HasPrefix(line, []byte("/*@ sourceMappingURL=")):
+		return bytes.HasSuffix(line, []byte("*/"))
+	}
+	return false
+}
+
+// hideFS 使匹配 patterns 的文件在底层文件系统中不可见
+type hideFS struct {
+	fsys     fs.FS
+	patterns []string
+}
+
+// hidden 判断 name 或它的某一级父目录是否被隐藏
+func (hfs *hideFS) hidden(name string) bool {
+	for ; name != "." && name != "/" && name != ""; name = path.Dir(name) {
+		if matchAny(hfs.patterns, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (hfs *hideFS) Open(name string) (fs.File, error) {
+	if hfs.hidden(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	f, err := hfs.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return &hideDir{File: f, hfs: hfs, name: name}, nil
+	}
+	return f, nil
+}
+
+func (hfs *hideFS) Stat(name string) (fs.FileInfo, error) {
+	if hfs.hidden(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return fs.Stat(hfs.fsys, name)
+}
+
+func (hfs *hideFS) ReadFile(name string) ([]byte, error) {
+	if hfs.hidden(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+	}
+	return fs.ReadFile(hfs.fsys, name)
+}
+
+func (hfs *hideFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if hfs.hidden(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(hfs.fsys, name)
+	visible := entries[:0:0]
+	for _, entry := range entries {
+		if !hfs.hidden(path.Join(name, entry.Name())) {
+			visible = append(visible, entry)
+		}
+	}
+	return visible, err
+}
+
+// hideDir 是 hideFS 中的目录, ReadDir 不返回被隐藏的条目
+type hideDir struct {
+	fs.File
+	hfs  *hideFS
+	name string
+
+	entries []fs.DirEntry
+	loaded  bool
+	offset  int
+}
+
+func (hd *hideDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !hd.loaded {
+		entries, err := hd.hfs.ReadDir(hd.name)
+		if err != nil {
+			return nil, err
+		}
+		hd.entries, hd.loaded = entries, true
+	}
+	return readDirPage(hd.entries, &hd.offset, count)
+}
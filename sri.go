@@ -0,0 +1,51 @@
+package modembed
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"io"
+	"io/fs"
+)
+
+// sriEntry 缓存一个文件的 SRI 字符串, 失效规则同 digestEntry
+type sriEntry struct {
+	stamp cacheStamp
+	value string
+}
+
+// sriValue 返回 data 的 SRI 字符串
+func sriValue(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// SRI 返回 name 对应文件的子资源完整性 (Subresource Integrity) 字符串, 形如 "sha384-..."
+// 哈希针对实际提供的内容 (包括转换的结果), 结果会被缓存
+func (mfs *ModTimeFS) SRI(name string) (string, error) {
+	name = cleanPath(name)
+	stamp, info, err := mfs.stampOf(name)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", &fs.PathError{Op: "sri", Path: name, Err: ErrIsDirectory}
+	}
+	if v, ok := mfs.integrity.Load(name); ok {
+		if e := v.(*sriEntry); e.stamp.matches(stamp) {
+			return e.value, nil
+		}
+	}
+
+	f, err := mfs.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha512.New384()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	e := &sriEntry{stamp: stamp, value: "sha384-" + base64.StdEncoding.EncodeToString(h.Sum(nil))}
+	mfs.integrity.Store(name, e)
+	return e.value, nil
+}
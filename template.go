@@ -0,0 +1,159 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+// templateFiles 返回匹配 pattern 的所有文件, 不匹配任何文件时返回错误
+func (mfs *ModTimeFS) templateFiles(pattern string) ([]string, error) {
+	matches, err := mfs.Glob(cleanPath(pattern))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("modembed: pattern matches no files: %#q", pattern)
+	}
+	return matches, nil
+}
+
+// ParseTemplates 从文件系统中解析匹配 pattern 的 html/template 模板
+// 与 template.ParseFS 相同, 每个模板以文件名 (不含目录) 命名, 返回的模板以第一个匹配的文件命名
+// funcs 在解析之前注册, 可以为 nil
+func (mfs *ModTimeFS) ParseTemplates(pattern string, funcs template.FuncMap) (*template.Template, error) {
+	matches, err := mfs.templateFiles(pattern)
+	if err != nil {
+		return nil, err
+	}
+	t := template.New(path.Base(matches[0])).Funcs(funcs)
+	for _, name := range matches {
+		data, err := mfs.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		// 与 ParseFS 相同, 与 t 同名的文件直接解析到 t 中
+		tmpl := t
+		if base := path.Base(name); base != t.Name() {
+			tmpl = t.New(base)
+		}
+		if _, err := tmpl.Parse(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// ParseTextTemplates 与 ParseTemplates 相同, 但使用 text/template
+func (mfs *ModTimeFS) ParseTextTemplates(pattern string, funcs texttemplate.FuncMap) (*texttemplate.Template, error) {
+	matches, err := mfs.templateFiles(pattern)
+	if err != nil {
+		return nil, err
+	}
+	t := texttemplate.New(path.Base(matches[0])).Funcs(funcs)
+	for _, name := range matches {
+		data, err := mfs.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		// 与 ParseFS 相同, 与 t 同名的文件直接解析到 t 中
+		tmpl := t
+		if base := path.Base(name); base != t.Name() {
+			tmpl = t.New(base)
+		}
+		if _, err := tmpl.Parse(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Templates 是开发模式下的 html/template 模板集合
+// 每次使用前检查匹配文件的列表, 大小与修改时间, 有变化时重新解析
+// 与 OverlayFS 配合使用时, 修改磁盘上的模板无需重启即可生效; 可以被多个 goroutine 并发使用
+type Templates struct {
+	mfs     *ModTimeFS
+	pattern string
+	funcs   template.FuncMap
+
+	mu    sync.Mutex
+	tmpl  *template.Template
+	stamp []templateStamp
+}
+
+type templateStamp struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+// ReloadingTemplates 返回按需重新解析的模板集合, 参数同 ParseTemplates
+// 解析在第一次调用 Get 或 ExecuteTemplate 时进行
+func (mfs *ModTimeFS) ReloadingTemplates(pattern string, funcs template.FuncMap) *Templates {
+	return &Templates{mfs: mfs, pattern: pattern, funcs: funcs}
+}
+
+// Get 返回当前的模板, 匹配的文件发生变化时先重新解析
+// 重新解析失败时返回错误, 之后的调用会再次尝试
+func (t *Templates) Get() (*template.Template, error) {
+	stamp, err := t.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tmpl != nil && sameStamp(t.stamp, stamp) {
+		return t.tmpl, nil
+	}
+	tmpl, err := t.mfs.ParseTemplates(t.pattern, t.funcs)
+	if err != nil {
+		return nil, err
+	}
+	t.tmpl, t.stamp = tmpl, stamp
+	return tmpl, nil
+}
+
+// ExecuteTemplate 使用当前的模板执行名为 name 的模板
+func (t *Templates) ExecuteTemplate(w io.Writer, name string, data any) error {
+	tmpl, err := t.Get()
+	if err != nil {
+		return err
+	}
+	return tmpl.ExecuteTemplate(w, name, data)
+}
+
+// snapshot 记录当前匹配的文件及其大小与修改时间
+func (t *Templates) snapshot() ([]templateStamp, error) {
+	matches, err := t.mfs.templateFiles(t.pattern)
+	if err != nil {
+		return nil, err
+	}
+	stamp := make([]templateStamp, len(matches))
+	for i, name := range matches {
+		info, err := fs.Stat(t.mfs, name)
+		if err != nil {
+			return nil, err
+		}
+		stamp[i] = templateStamp{name: name, size: info.Size(), modTime: info.ModTime()}
+	}
+	return stamp, nil
+}
+
+func sameStamp(a, b []templateStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].name != b[i].name || a[i].size != b[i].size || !a[i].modTime.Equal(b[i].modTime) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithBandwidthLimit 将匹配 patterns (为空时为全部文件) 的响应消息体的总速率限制为每秒 bytesPerSecond 字节
+// 所有匹配的请求共享同一个限额, 例如限制离线包或视频的下载, 避免它们占满与 API 共用的带宽
+// 可以多次使用, 例如一个不带 patterns 的全局限额加上对 "videos/*" 更低的限额; 请求需要满足所有匹配的限额
+// 等待中的请求在客户端断开时停止; bytesPerSecond <= 0 时不限制
+func WithBandwidthLimit(bytesPerSecond int64, patterns ...string) HandlerOption {
+	return func(h *handler) {
+		if bytesPerSecond <= 0 {
+			return
+		}
+		h.throttles = append(h.throttles, throttleRule{patterns: patterns, limiter: newRateLimiter(bytesPerSecond)})
+	}
+}
+
+type throttleRule struct {
+	patterns []string
+	limiter  *rateLimiter
+}
+
+// throttle 在 name 匹配任意限额时返回限制写出速率的 w
+func (h *handler) throttle(w http.ResponseWriter, r *http.Request, name string) http.ResponseWriter {
+	var limiters []*rateLimiter
+	for _, rule := range h.throttles {
+		if len(rule.patterns) == 0 || matchAny(rule.patterns, name) {
+			limiters = append(limiters, rule.limiter)
+		}
+	}
+	if len(limiters) == 0 {
+		return w
+	}
+	return &throttledWriter{ResponseWriter: w, ctx: r.Context(), limiters: limiters}
+}
+
+// throttledWriter 按限额分块写出消息体; 不实现 io.ReaderFrom, 使所有写入都经过 Write
+type throttledWriter struct {
+	http.ResponseWriter
+	ctx      context.Context
+	limiters []*rateLimiter
+}
+
+func (tw *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := len(p)
+		for _, l := range tw.limiters {
+			n = min(n, l.chunk)
+		}
+		for _, l := range tw.limiters {
+			if err := l.wait(tw.ctx, n); err != nil {
+				return written, err
+			}
+		}
+		m, err := tw.ResponseWriter.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+func (tw *throttledWriter) Unwrap() http.ResponseWriter { return tw.ResponseWriter }
+
+// rateLimiter 是按预约等待的令牌桶, 桶容量为一个写出块
+type rateLimiter struct {
+	rate  float64 // 每秒字节数
+	chunk int     // 单次写出的最大字节数, 约为 0.1 秒的限额
+
+	mu     sync.Mutex
+	tokens float64 // 可以为负, 表示已经预约的未来额度
+	last   time.Time
+}
+
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	chunk := int(min(max(bytesPerSecond/10, 1<<10), 64<<10))
+	return &rateLimiter{rate: float64(bytesPerSecond), chunk: chunk, tokens: float64(chunk)}
+}
+
+// wait 预约 n 字节的额度, 额度不足时等待到预约的时间或 ctx 结束
+// 限速针对真实的时间, 不使用 WithClock 设置的时钟
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, float64(l.chunk))
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// 归还未使用的额度
+		l.mu.Lock()
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
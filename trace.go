@@ -0,0 +1,57 @@
+package modembed
+
+import (
+	"context"
+)
+
+// Handler 与 ModTimeFS 创建的追踪区间名称
+const (
+	SpanServe     = "modembed.serve"     // Handler 处理一个请求
+	SpanOpen      = "modembed.open"      // OpenContext 打开一个文件
+	SpanTransform = "modembed.transform" // 取得 WithTransform 的转换结果
+	SpanCompress  = "modembed.compress"  // 取得 WithCompression 的压缩结果
+)
+
+// 追踪区间使用的属性
+const (
+	AttrPath       = "modembed.path"             // 清理后的路径, string
+	AttrSize       = "modembed.size"             // 内容 (转换或压缩后) 的字节数, int64
+	AttrEncoding   = "modembed.encoding"         // 响应的 Content-Encoding, string
+	AttrCacheHit   = "modembed.cache_hit"        // 结果是否来自缓存, bool
+	AttrSource     = "modembed.source"           // 打开的文件来自 "virtual", "memory" 或 "fs", string
+	AttrStatusCode = "http.response.status_code" // 响应状态码, int
+	AttrBodySize   = "http.response.body.size"   // 写出的消息体字节数, int64
+)
+
+// Tracer 为 Handler 与 ModTimeFS 的各阶段创建追踪区间, 实现需要可以被多个 goroutine 并发调用
+// 基于 OpenTelemetry 的实现见 modembedotel 子包
+type Tracer interface {
+	// Start 以 ctx 为父区间开始名为 name 的区间, 返回带有新区间的 context
+	Start(ctx context.Context, name string) (context.Context, TraceSpan)
+}
+
+// TraceSpan 是一个进行中的追踪区间
+type TraceSpan interface {
+	// SetAttr 设置属性, value 为 string, bool, int 或 int64
+	SetAttr(key string, value any)
+	// End 结束区间, err 非 nil 时记录为错误
+	End(err error)
+}
+
+type tracerKey struct{}
+
+// ContextWithTracer 返回携带 t 的 context, 以它调用 OpenContext 时打开与转换会创建追踪区间
+// WithTracer 会对每个请求的 context 自动设置
+func ContextWithTracer(ctx context.Context, t Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// startSpan 在 ctx 携带 Tracer 时开始区间, 否则返回 ctx 与 nil
+// 调用方在设置属性之前检查 nil, 使未启用追踪时不产生额外的分配
+func startSpan(ctx context.Context, name string) (context.Context, TraceSpan) {
+	t, _ := ctx.Value(tracerKey{}).(Tracer)
+	if t == nil {
+		return ctx, nil
+	}
+	return t.Start(ctx, name)
+}
@@ -0,0 +1,109 @@
+package modembed
+
+import (
+	"context"
+	"io/fs"
+	"time"
+)
+
+// TransformFunc 对文件内容进行转换, 例如压缩空白, 替换 index.html 中的环境变量或插入版权声明
+// 返回的切片会被缓存并在之后的读取中共享, 转换函数不应在返回后继续修改它
+type TransformFunc func(path string, data []byte) ([]byte, error)
+
+type transformRule struct {
+	pattern string
+	fn      TransformFunc
+}
+
+// WithTransform 为匹配 pattern 的文件注册内容转换
+// 同一文件匹配多个转换时按注册顺序依次应用, 结果在首次访问时计算并缓存在内存中
+// Open, ReadFile, Stat 与 ReadDir 返回的内容和 Size 都反映转换后的结果, ModTime 保持不变
+// pattern 语法同 CacheRule
+func WithTransform(pattern string, fn TransformFunc) Option {
+	return func(o *options) {
+		o.transforms = append(o.transforms, transformRule{pattern: pattern, fn: fn})
+	}
+}
+
+// transformEntry 缓存一个文件的转换结果
+// 与 digestEntry 相同, 记录底层文件的大小与修改时间用于失效判断
+type transformEntry struct {
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+// hasTransform 判断 name 是否有匹配的转换
+func (mfs *ModTimeFS) hasTransform(name string) bool {
+	for _, t := range mfs.transforms {
+		if matchPattern(t.pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// transformed 返回 name 转换后的内容, info 为底层文件的 FileInfo
+// 调用方应先通过 hasTransform 确认存在匹配的转换
+// ctx 结束时放弃等待, 转换在所有等待它的调用都结束后停止 (在两个转换函数之间检查)
+func (mfs *ModTimeFS) transformed(ctx context.Context, name string, info fs.FileInfo) ([]byte, error) {
+	name = cleanPath(name)
+	ctx, span := startSpan(ctx, SpanTransform)
+	data, hit, err := mfs.transform(ctx, name, info)
+	if span != nil {
+		span.SetAttr(AttrPath, name)
+		span.SetAttr(AttrCacheHit, hit)
+		if err == nil {
+			span.SetAttr(AttrSize, int64(len(data)))
+		}
+		span.End(err)
+	}
+	return data, err
+}
+
+// transform 返回 name 转换后的内容以及它是否来自缓存
+func (mfs *ModTimeFS) transform(ctx context.Context, name string, info fs.FileInfo) ([]byte, bool, error) {
+	if e, ok := mfs.cachedTransform(name); ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		return e.data, true, nil
+	}
+	// 并发的首次请求只转换一次
+	data, err := mfs.transformFlight.do(ctx, name, func(ctx context.Context) ([]byte, error) {
+		data, err := fs.ReadFile(mfs.FS, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range mfs.transforms {
+			if !matchPattern(t.pattern, name) {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if data, err = t.fn(name, data); err != nil {
+				return nil, &fs.PathError{Op: "transform", Path: name, Err: err}
+			}
+		}
+		mfs.storeTransform(name, &transformEntry{size: info.Size(), modTime: info.ModTime(), data: data})
+		return data, nil
+	})
+	return data, false, err
+}
+
+// cachedTransform 返回缓存的 name 的转换结果
+func (mfs *ModTimeFS) cachedTransform(name string) (*transformEntry, bool) {
+	if mfs.budget != nil {
+		return newBudgetRegion[*transformEntry](mfs.budget, CacheTransform, mfs).get(name)
+	}
+	if v, ok := mfs.transformCache.Load(name); ok {
+		return v.(*transformEntry), true
+	}
+	return nil, false
+}
+
+func (mfs *ModTimeFS) storeTransform(name string, e *transformEntry) {
+	if mfs.budget != nil {
+		newBudgetRegion[*transformEntry](mfs.budget, CacheTransform, mfs).add(name, e, int64(len(e.data)))
+		return
+	}
+	mfs.transformCache.Store(name, e)
+}
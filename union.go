@@ -0,0 +1,173 @@
+package modembed
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// Union 将多个文件系统合并到同一个挂载点, 靠后的文件系统遮蔽靠前的同名文件
+// 同名目录的内容会被合并; 每个来源可以是各自带有 ModTime 设定的 *ModTimeFS, 也可以是普通的 fs.FS
+func Union(fss ...fs.FS) *ModTimeFS {
+	layers := make([]fs.FS, len(fss))
+	for i, fsys := range fss {
+		layers[len(fss)-1-i] = fsys
+	}
+	// 零值时间使每个文件沿用其来源报告的修改时间
+	return NewModTimeFSFromFS(&layeredFS{layers: layers}, time.Time{})
+}
+
+// layeredFS 将多个文件系统叠加在一起, layers[0] 的优先级最高
+// 文件由第一个包含它的层提供, 目录则合并所有层中同名目录的内容
+type layeredFS struct {
+	layers []fs.FS
+}
+
+// notInLayer 判断 err 是否表示当前层中没有 name, 此时继续查找下一层
+// 路径中的某个组成部分在该层是文件时 (例如磁盘覆盖层中的 docs 遮蔽了嵌入的 docs/ 目录), os.DirFS 返回 ENOTDIR 而不是 ErrNotExist
+func notInLayer(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR)
+}
+
+func (lfs *layeredFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for i, layer := range lfs.layers {
+		f, err := layer.Open(name)
+		if notInLayer(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if !info.IsDir() {
+			return f, nil
+		}
+		return &layeredDir{File: f, lfs: lfs, name: name, from: i}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (lfs *layeredFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range lfs.layers {
+		info, err := fs.Stat(layer, name)
+		if notInLayer(err) {
+			continue
+		}
+		return info, err
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (lfs *layeredFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range lfs.layers {
+		data, err := fs.ReadFile(layer, name)
+		if notInLayer(err) {
+			continue
+		}
+		return data, err
+	}
+	return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+}
+
+func (lfs *layeredFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	for i, layer := range lfs.layers {
+		info, err := fs.Stat(layer, name)
+		if notInLayer(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: ErrNotDirectory}
+		}
+		return lfs.mergeDir(name, i)
+	}
+	return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+}
+
+// mergeDir 合并从 layers[from] 开始所有层中名为 name 的目录
+// 同名条目由优先级最高的层提供, 结果按名称排序
+func (lfs *layeredFS) mergeDir(name string, from int) ([]fs.DirEntry, error) {
+	seen := make(map[string]bool)
+	var merged []fs.DirEntry
+	for _, layer := range lfs.layers[from:] {
+		entries, err := fs.ReadDir(layer, name)
+		if err != nil {
+			// 其他层中不存在该目录或同名的是一个文件, 都只是被遮蔽, 不视为错误
+			continue
+		}
+		for _, entry := range entries {
+			if !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				merged = append(merged, entry)
+			}
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name() < merged[j].Name() })
+	return merged, nil
+}
+
+// layeredDir 是 layeredFS 中打开的目录, ReadDir 返回合并后的内容
+type layeredDir struct {
+	fs.File
+	lfs  *layeredFS
+	name string
+	from int
+
+	entries []fs.DirEntry // 首次 ReadDir 时填充
+	loaded  bool
+	offset  int
+}
+
+func (ld *layeredDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !ld.loaded {
+		entries, err := ld.lfs.mergeDir(ld.name, ld.from)
+		if err != nil {
+			return nil, err
+		}
+		ld.entries, ld.loaded = entries, true
+	}
+	return readDirPage(ld.entries, &ld.offset, count)
+}
+
+// readDirPage 按 fs.ReadDirFile 的语义从 entries 的 offset 处返回最多 count 个条目
+// count <= 0 时返回剩余的全部条目与 nil, 否则在没有剩余条目时返回 io.EOF
+func readDirPage(entries []fs.DirEntry, offset *int, count int) ([]fs.DirEntry, error) {
+	rest := entries[*offset:]
+	if count <= 0 {
+		*offset = len(entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(rest) {
+		count = len(rest)
+	}
+	*offset += count
+	return rest[:count], nil
+}
+
+var _ fs.ReadDirFS = (*layeredFS)(nil)
+var _ fs.ReadFileFS = (*layeredFS)(nil)
+var _ fs.StatFS = (*layeredFS)(nil)
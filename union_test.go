@@ -0,0 +1,42 @@
+package modembed
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// 磁盘覆盖层中的文件与嵌入的目录同名时, 目录下的文件仍由嵌入的层提供
+func TestOverlayFileShadowsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docs"), []byte("disk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	embedded := New(fstest.MapFS{
+		"docs/a.html":     {Data: []byte("a")},
+		"docs/sub/b.html": {Data: []byte("b")},
+	}, WithModTime(time.Unix(1e9, 0)))
+	o := OverlayFS(dir, embedded)
+
+	for _, name := range []string{"docs/a.html", "docs/sub/b.html"} {
+		want, _ := embedded.ReadFile(name)
+		if data, err := o.ReadFile(name); err != nil || string(data) != string(want) {
+			t.Errorf("ReadFile(%s) = %q, %v; want %q", name, data, err, want)
+		}
+		if data, err := fs.ReadFile(struct{ fs.FS }{o}, name); err != nil || string(data) != string(want) {
+			t.Errorf("Open(%s) read %q, %v; want %q", name, data, err, want)
+		}
+		if _, err := o.Stat(name); err != nil {
+			t.Errorf("Stat(%s) = %v", name, err)
+		}
+	}
+	if entries, err := o.ReadDir("docs/sub"); err != nil || len(entries) != 1 {
+		t.Errorf("ReadDir(docs/sub) = %v, %v; want b.html", entries, err)
+	}
+	if data, err := o.ReadFile("docs"); err != nil || string(data) != "disk" {
+		t.Errorf("ReadFile(docs) = %q, %v; want the disk file", data, err)
+	}
+}
@@ -0,0 +1,82 @@
+package modembed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// VerifyError 描述 Verify 发现的所有不一致
+type VerifyError struct {
+	Mismatched []string // 内容哈希与预期不同的文件
+	Missing    []string // 预期存在但没有嵌入的文件
+	Unexpected []string // 嵌入了但不在预期清单中的文件
+}
+
+func (e *VerifyError) Error() string {
+	var b strings.Builder
+	b.WriteString("modembed: embedded files do not match the expected manifest")
+	for _, group := range []struct {
+		label string
+		names []string
+	}{
+		{"mismatched", e.Mismatched},
+		{"missing", e.Missing},
+		{"unexpected", e.Unexpected},
+	} {
+		if len(group.names) > 0 {
+			fmt.Fprintf(&b, "\n\t%s (%d): %s", group.label, len(group.names), strings.Join(group.names, ", "))
+		}
+	}
+	return b.String()
+}
+
+// Verify 对所有嵌入的文件计算 SHA-256 并与 expected (路径 -> 十六进制哈希) 比较
+// 适合作为启动时的自检, 确认二进制中的资源与 CI 产出的一致; expected 通常来自 modembed-gen -hash 生成的清单 (Manifest.Hashes)
+// 与 modembed-gen 相同, 哈希针对嵌入的原始字节与原始路径, 不经过转换, 解压, 别名与隐藏, 也不包括虚拟文件;
+// 这与 ModTimeFS.Manifest 记录的提供内容的哈希不同; 全部一致时返回 nil, 否则返回 *VerifyError
+func (mfs *ModTimeFS) Verify(expected map[string]string) error {
+	want := make(map[string]string, len(expected))
+	for name, sum := range expected {
+		want[cleanPath(name)] = strings.ToLower(sum)
+	}
+	source := mfs.source
+	if source == nil {
+		source = mfs.FS
+	}
+	verr := &VerifyError{}
+	err := fs.WalkDir(source, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		sum, ok := want[name]
+		if !ok {
+			verr.Unexpected = append(verr.Unexpected, name)
+			return nil
+		}
+		delete(want, name)
+		data, err := fs.ReadFile(source, name)
+		if err != nil {
+			return err
+		}
+		got := sha256.Sum256(data)
+		if hex.EncodeToString(got[:]) != sum {
+			verr.Mismatched = append(verr.Mismatched, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for name := range want {
+		verr.Missing = append(verr.Missing, name)
+	}
+	sort.Strings(verr.Missing)
+	if len(verr.Mismatched)+len(verr.Missing)+len(verr.Unexpected) == 0 {
+		return nil
+	}
+	return verr
+}
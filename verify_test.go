@@ -0,0 +1,56 @@
+package modembed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// rawHashes 返回 m 中每个文件原始内容的 SHA-256, 与 modembed-gen -hash 的结果相同
+func rawHashes(m fstest.MapFS) map[string]string {
+	hashes := make(map[string]string, len(m))
+	for name, f := range m {
+		sum := sha256.Sum256(f.Data)
+		hashes[name] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
+func TestVerifyRawContent(t *testing.T) {
+	m := fstest.MapFS{
+		"index.html": {Data: []byte("<p>  hello  </p>")},
+		"app.js":     {Data: []byte("console.log(1)\n//# sourceMappingURL=app.js.map\n")},
+		"app.js.map": {Data: []byte("{}")},
+	}
+	// 转换, 隐藏与虚拟文件都不影响 Verify
+	mfs := New(m, WithModTime(time.Unix(1e9, 0)), WithSourceMaps(false), WithTransform("*.html", func(_ string, data []byte) ([]byte, error) {
+		return bytes.ReplaceAll(data, []byte("  "), nil), nil
+	}))
+	mfs.AddVirtual("version.txt", []byte("v1"), time.Time{})
+
+	hashes := rawHashes(m)
+	if err := mfs.Verify(hashes); err != nil {
+		t.Fatalf("Verify(raw hashes) = %v", err)
+	}
+
+	hashes["app.js"] = hashes["index.html"]
+	delete(hashes, "app.js.map")
+	hashes["gone.css"] = hashes["index.html"]
+	var verr *VerifyError
+	if err := mfs.Verify(hashes); !errors.As(err, &verr) {
+		t.Fatalf("Verify = %v, want *VerifyError", err)
+	}
+	if len(verr.Mismatched) != 1 || verr.Mismatched[0] != "app.js" {
+		t.Errorf("Mismatched = %v, want [app.js]", verr.Mismatched)
+	}
+	if len(verr.Missing) != 1 || verr.Missing[0] != "gone.css" {
+		t.Errorf("Missing = %v, want [gone.css]", verr.Missing)
+	}
+	if len(verr.Unexpected) != 1 || verr.Unexpected[0] != "app.js.map" {
+		t.Errorf("Unexpected = %v, want [app.js.map]", verr.Unexpected)
+	}
+}
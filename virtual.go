@@ -0,0 +1,166 @@
+package modembed
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// virtualFile 是通过 AddVirtual 注册的内存文件
+type virtualFile struct {
+	data    []byte
+	modTime time.Time
+	fi      fs.FileInfo // 注册时创建, 多次 Stat 返回同一个值
+}
+
+// virtualSet 是虚拟文件的一个不可变快照, 修改时整体替换
+type virtualSet struct {
+	files map[string]*virtualFile
+	dirs  map[string]map[string]bool // 目录 -> 由虚拟文件隐含的直接子项名称
+}
+
+// AddVirtual 注册一个内存中的虚拟文件, 它与底层文件一起出现在 Open, Stat, ReadFile 与 ReadDir 中
+// 虚拟文件会遮蔽同名的底层文件, 路径中不存在的父目录会被自动补全
+// modTime 为零值时按普通文件的规则决定修改时间; 可以在运行时并发调用
+func (mfs *ModTimeFS) AddVirtual(name string, data []byte, modTime time.Time) {
+	name = cleanPath(name)
+	if mfs.normTime != nil {
+		modTime = mfs.normTime(modTime)
+	}
+	mfs.updateVirtual(func(files map[string]*virtualFile) {
+		vf := &virtualFile{data: bytes.Clone(data), modTime: modTime}
+		vf.fi = &virtualInfo{name: path.Base(name), size: int64(len(vf.data)), mode: 0o444, modTime: modTime}
+		files[name] = vf
+	})
+	mfs.digests.Delete(name)
+	mfs.integrity.Delete(name)
+	mfs.dataURIs.Delete(name)
+	mfs.InvalidateMisses(name)
+}
+
+// RemoveVirtual 移除之前注册的虚拟文件
+func (mfs *ModTimeFS) RemoveVirtual(name string) {
+	name = cleanPath(name)
+	mfs.updateVirtual(func(files map[string]*virtualFile) {
+		delete(files, name)
+	})
+	mfs.digests.Delete(name)
+	mfs.integrity.Delete(name)
+	mfs.dataURIs.Delete(name)
+}
+
+func (mfs *ModTimeFS) updateVirtual(update func(map[string]*virtualFile)) {
+	mfs.virtualMu.Lock()
+	defer mfs.virtualMu.Unlock()
+	files := make(map[string]*virtualFile)
+	if old := mfs.virtual.Load(); old != nil {
+		for name, vf := range old.files {
+			files[name] = vf
+		}
+	}
+	update(files)
+	if len(files) == 0 {
+		mfs.virtual.Store(nil)
+		return
+	}
+	dirs := make(map[string]map[string]bool)
+	for name := range files {
+		for child := name; child != "."; child = path.Dir(child) {
+			dir := path.Dir(child)
+			if dirs[dir] == nil {
+				dirs[dir] = make(map[string]bool)
+			}
+			dirs[dir][path.Base(child)] = true
+		}
+	}
+	mfs.virtual.Store(&virtualSet{files: files, dirs: dirs})
+}
+
+// virtualFileOf 返回 name 对应的虚拟文件
+func (mfs *ModTimeFS) virtualFileOf(name string) *virtualFile {
+	if vs := mfs.virtual.Load(); vs != nil {
+		return vs.files[name]
+	}
+	return nil
+}
+
+// virtualChildren 返回由虚拟文件隐含在目录 name 下的子项, 不是虚拟目录时返回 nil
+func (mfs *ModTimeFS) virtualChildren(name string) map[string]bool {
+	if vs := mfs.virtual.Load(); vs != nil {
+		return vs.dirs[name]
+	}
+	return nil
+}
+
+// rawStat 返回 name 未经包装的 FileInfo, 包括虚拟文件与虚拟目录
+func (mfs *ModTimeFS) rawStat(name string) (fs.FileInfo, error) {
+	if vf := mfs.virtualFileOf(name); vf != nil {
+		return vf.fi, nil
+	}
+	if err := mfs.cachedMiss("stat", name); err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(mfs.FS, name)
+	if err != nil {
+		if mfs.virtualChildren(name) != nil {
+			return virtualDirInfo(name), nil
+		}
+		mfs.recordMiss("stat", name, err)
+	}
+	return info, err
+}
+
+// mergeVirtual 将目录 name 的底层条目与虚拟子项合并, 虚拟文件遮蔽同名的底层条目
+func (mfs *ModTimeFS) mergeVirtual(name string, entries []fs.DirEntry, children map[string]bool) []fs.DirEntry {
+	merged := make([]fs.DirEntry, 0, len(entries)+len(children))
+	for _, entry := range entries {
+		if !children[entry.Name()] {
+			merged = append(merged, entry)
+			continue
+		}
+		if mfs.virtualFileOf(path.Join(name, entry.Name())) == nil && entry.IsDir() {
+			// 虚拟目录与底层目录同名, 保留底层的条目
+			merged = append(merged, entry)
+			delete(children, entry.Name())
+		}
+	}
+	for child := range children {
+		full := path.Join(name, child)
+		if vf := mfs.virtualFileOf(full); vf != nil {
+			merged = append(merged, fs.FileInfoToDirEntry(vf.fi))
+		} else {
+			merged = append(merged, fs.FileInfoToDirEntry(virtualDirInfo(full)))
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name() < merged[j].Name() })
+	return merged
+}
+
+// --- 虚拟文件的 fs.FileInfo  ---
+type virtualInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func virtualDirInfo(name string) fs.FileInfo {
+	return &virtualInfo{name: path.Base(name), mode: fs.ModeDir | 0o555}
+}
+
+func (vi *virtualInfo) Name() string       { return vi.name }
+func (vi *virtualInfo) Size() int64        { return vi.size }
+func (vi *virtualInfo) Mode() fs.FileMode  { return vi.mode }
+func (vi *virtualInfo) ModTime() time.Time { return vi.modTime }
+func (vi *virtualInfo) IsDir() bool        { return vi.mode.IsDir() }
+func (vi *virtualInfo) Sys() interface{}   { return nil }
+
+// globFS 只暴露 Open 与 ReadDir, 使 fs.Glob 通过 ModTimeFS.ReadDir 匹配 (从而包含虚拟文件)
+type globFS struct {
+	mfs *ModTimeFS
+}
+
+func (g globFS) Open(name string) (fs.File, error)          { return g.mfs.Open(name) }
+func (g globFS) ReadDir(name string) ([]fs.DirEntry, error) { return g.mfs.ReadDir(name) }
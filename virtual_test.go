@@ -0,0 +1,70 @@
+package modembed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// 与 AddVirtual 并发的 digest 可能在新的虚拟文件发布之后才写入旧内容的哈希
+// 大小与修改时间相同的重新注册不能因此命中旧的缓存
+func TestAddVirtualStaleDigest(t *testing.T) {
+	mfs := New(fstest.MapFS{}, WithModTime(time.Unix(1e9, 0)))
+	mfs.AddVirtual("app.js", []byte("old"), time.Time{})
+	stale, _, err := mfs.stampOf("app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mfs.AddVirtual("app.js", []byte("new"), time.Time{})
+	// 模拟在 AddVirtual 之后才完成的旧计算
+	mfs.digests.Store("app.js", &digestEntry{stamp: stale, sum: sha256.Sum256([]byte("old"))})
+
+	got, err := mfs.SHA256("app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha256.Sum256([]byte("new")); got != hex.EncodeToString(want[:]) {
+		t.Errorf("SHA256 = %s, want hash of new content", got)
+	}
+}
+
+func TestAddVirtualConcurrentETag(t *testing.T) {
+	mfs := New(fstest.MapFS{}, WithModTime(time.Unix(1e9, 0)))
+	contents := []string{"aaaa", "bbbb", "cccc", "dddd"}
+	mfs.AddVirtual("app.js", []byte(contents[0]), time.Time{})
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					mfs.ETag("app.js")
+				}
+			}
+		}()
+	}
+	for i := 0; i < 200; i++ {
+		mfs.AddVirtual("app.js", []byte(contents[i%len(contents)]), time.Time{})
+	}
+	close(stop)
+	wg.Wait()
+
+	final := []byte(contents[199%len(contents)])
+	got, err := mfs.SHA256("app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha256.Sum256(final); got != hex.EncodeToString(want[:]) {
+		t.Errorf("SHA256 = %s, want hash of %q", got, final)
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !modembed_core && !tinygo
+
+package modembed
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// DefaultManifestRoute 是 WithManifestRoute 默认使用的路径
+const DefaultManifestRoute = ".well-known/assets.json"
+
+// WithManifestRoute 让 Handler 在 name (为空时为 /.well-known/assets.json) 上以 JSON 提供 ModTimeFS.Manifest
+// 包含每个文件的路径, 大小, SHA-256 与 ModTime, 供部署工具与 CDN 核对运行中的实例嵌入了哪些内容
+// 被 WithDeny 拒绝的文件不会出现在列表中; 列表在每次请求时生成, 哈希本身是缓存的
+func WithManifestRoute(name string) HandlerOption {
+	if name == "" {
+		name = DefaultManifestRoute
+	}
+	name = cleanPath(name)
+	return func(h *handler) {
+		h.route(name, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m, err := h.fsys.Manifest()
+			if err != nil {
+				h.serveError(w, r, err)
+				return
+			}
+			visible := m[:0]
+			for _, e := range m {
+				if !h.denied(e.Path) {
+					visible = append(visible, e)
+				}
+			}
+			var buf bytes.Buffer
+			if err := visible.WriteJSON(&buf); err != nil {
+				h.serveError(w, r, err)
+				return
+			}
+			latest := h.fsys.ModTime()
+			for _, e := range visible {
+				if e.ModTime.After(latest) {
+					latest = e.ModTime
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-cache")
+			serveBytes(w, r, name, latest, buf.Bytes())
+		}))
+	}
+}
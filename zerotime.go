@@ -0,0 +1,74 @@
+package modembed
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// ZeroTimePolicy 决定统一修改时间为零值时 New 的行为
+// 零值的 ModTime 会让 Last-Modified 缺失, If-Modified-Since 永远无法命中
+type ZeroTimePolicy int
+
+const (
+	ZeroTimeKeep         ZeroTimePolicy = iota // 保持零值, 即底层文件系统的行为 (默认)
+	ZeroTimeError                              // NewChecked 返回 ErrZeroModTime, New 以该错误 panic
+	ZeroTimeBuildTime                          // 使用 BuildTime, 无法获取时使用进程启动时间
+	ZeroTimeProcessStart                       // 使用进程启动时间 (设置了 WithClock 时为创建时的 Clock.Now)
+	ZeroTimeWarn                               // 保持零值, 但通过 WithZeroTimeLogger 设置的函数 (默认为标准错误) 输出警告
+)
+
+// WithZeroTimePolicy 设置统一修改时间为零值时的处理方式, 见 ZeroTimePolicy
+// 只检查 WithModTime 等设定的统一时间; 逐路径映射, 模式规则与 WithModTimeFunc 不受影响
+func WithZeroTimePolicy(p ZeroTimePolicy) Option {
+	return func(o *options) {
+		o.zeroPolicy = p
+	}
+}
+
+// WithZeroTimeLogger 设置 ZeroTimeWarn 输出警告的函数, 例如 log.Printf
+func WithZeroTimeLogger(logf func(format string, args ...any)) Option {
+	return func(o *options) {
+		o.zeroLogf = logf
+	}
+}
+
+// WithZeroTimeWarning 在最终的统一修改时间为零值时输出警告, 等价于 WithZeroTimePolicy(ZeroTimeWarn)
+func WithZeroTimeWarning() Option {
+	return WithZeroTimePolicy(ZeroTimeWarn)
+}
+
+// applyZeroPolicy 在统一修改时间为零值时执行 zeroPolicy
+func (o *options) applyZeroPolicy() error {
+	if !o.modTime.IsZero() {
+		return nil
+	}
+	switch o.zeroPolicy {
+	case ZeroTimeError:
+		return ErrZeroModTime
+	case ZeroTimeBuildTime:
+		if t, ok := BuildTime(); ok {
+			o.modTime = t
+			return nil
+		}
+		o.modTime = o.processStart()
+	case ZeroTimeProcessStart:
+		o.modTime = o.processStart()
+	case ZeroTimeWarn:
+		const msg = "modembed: New called with zero time, HTTP 304 caching might not work as expected"
+		if o.zeroLogf != nil {
+			o.zeroLogf("%s", msg)
+		} else {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+	}
+	return nil
+}
+
+// processStart 返回回退使用的进程启动时间, 设置了 Clock 时使用它的当前时间
+func (o *options) processStart() time.Time {
+	if o.clock != nil {
+		return o.clock.Now()
+	}
+	return processStart
+}
@@ -0,0 +1,24 @@
+package modembed
+
+import (
+	"archive/zip"
+	"bytes"
+	"time"
+)
+
+// NewFromZip 以 zip 归档为底层文件系统创建 ModTimeFS, 提供与 embed.FS 相同的语义
+// modTime 为零值时使用归档中每个文件记录的修改时间
+// zip 中的文件不支持 Seek, 需要时会读入内存, 因此 Range 请求与 http.ServeContent 仍然可用
+func NewFromZip(r *zip.Reader, modTime time.Time, opts ...Option) *ModTimeFS {
+	return New(r, append([]Option{WithModTime(modTime)}, opts...)...)
+}
+
+// NewFromZipBytes 与 NewFromZip 相同, 但接受完整的 zip 数据
+// 例如嵌入的单个归档文件 (//go:embed assets.zip) 或运行时下载的资源包
+func NewFromZipBytes(data []byte, modTime time.Time, opts ...Option) (*ModTimeFS, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	return NewChecked(r, append([]Option{WithModTime(modTime)}, opts...)...)
+}